@@ -0,0 +1,349 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultAbuseReportThreshold = 5
+
+	abuseStatusBanned = "banned"
+
+	reportStatusDismissed = "dismissed"
+	reportStatusActioned  = "actioned"
+)
+
+type ReportUrlRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=phishing malware spam illegal other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+type ReportUrlResponse struct {
+	Message string `json:"message"`
+}
+
+type ListReportsRequest struct {
+	Page  int32 `form:"page" binding:"min=0"`
+	Limit int32 `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type ReportedUrlResponse struct {
+	ShortCode      string     `json:"short_code"`
+	OriginalUrl    string     `json:"original_url"`
+	AbuseStatus    string     `json:"abuse_status,omitempty"`
+	ReportCount    int64      `json:"report_count"`
+	LastReportedAt *time.Time `json:"last_reported_at,omitempty"`
+	Reasons        []string   `json:"reasons"`
+}
+
+type AbuseReportResponse struct {
+	ID         int64      `json:"id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type BanDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) abuseReportThreshold() int64 {
+	if s.config.AbuseReportThreshold > 0 {
+		return int64(s.config.AbuseReportThreshold)
+	}
+	return defaultAbuseReportThreshold
+}
+
+// ReportUrl records a public abuse report. Each visitor gets one open report
+// per link, and the link is suspended once enough different visitors have
+// reported it. The response is the same either way so reporters cannot probe
+// the moderation state.
+func (s *Server) ReportUrl(ctx *gin.Context) {
+	var req ReportUrlRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: reason must be one of phishing, malware, spam, illegal, other"})
+		return
+	}
+
+	ip := ctx.ClientIP()
+	if !s.reportLimiter.Allow(ip) {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many reports, please try again later"})
+		return
+	}
+
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.ReportURLTx(ctx, db.ReportURLTxParams{
+		CreateAbuseReportParams: db.CreateAbuseReportParams{
+			UrlID:      urlRecord.ID,
+			Reason:     req.Reason,
+			Details:    pgtype.Text{String: strings.TrimSpace(req.Details), Valid: strings.TrimSpace(req.Details) != ""},
+			ReporterIp: ip,
+		},
+		Threshold:             s.abuseReportThreshold(),
+		InterstitialThreshold: s.interstitialThreshold(),
+	})
+	if err != nil {
+		fmt.Println("Error storing abuse report:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store report"})
+		return
+	}
+
+	if result.Suspended {
+		log.Printf("suspended %s after %d abuse reports", urlRecord.ShortCode, result.ReportCount)
+	}
+
+	ctx.JSON(http.StatusAccepted, ReportUrlResponse{Message: "Thank you, the report will be reviewed"})
+}
+
+// findModeratedUrl loads the link for the :short_code param, including links
+// that are already suspended, banned or taken down by the threat feed.
+func (s *Server) findModeratedUrl(ctx *gin.Context) (db.Url, bool) {
+	shortCode := ctx.Param("short_code")
+	if !utils.ValidateShortCode(shortCode) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid short code format"})
+		return db.Url{}, false
+	}
+
+	urlRecord, err := s.getURLByShortCode(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+			return db.Url{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
+		return db.Url{}, false
+	}
+
+	return urlRecord, true
+}
+
+// ListReportedUrls is the moderation queue: links with open reports, most
+// reported first, followed by links taken down by the threat feed.
+func (s *Server) ListReportedUrls(ctx *gin.Context) {
+	var req ListReportsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	rows, err := s.store.ListReportedURLs(ctx, db.ListReportedURLsParams{
+		Limit:  req.Limit,
+		Offset: req.Page * req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	total, err := s.store.CountReportedURLs(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count reports"})
+		return
+	}
+
+	urls := make([]ReportedUrlResponse, len(rows))
+	for i, row := range rows {
+		urls[i] = ReportedUrlResponse{
+			ShortCode:      row.ShortCode,
+			OriginalUrl:    row.OriginalUrl,
+			AbuseStatus:    row.AbuseStatus.String,
+			ReportCount:    row.ReportCount,
+			LastReportedAt: timestampPtr(row.LastReportedAt),
+			Reasons:        row.Reasons,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  urls,
+		"total": total,
+		"page":  req.Page,
+		"limit": req.Limit,
+	})
+}
+
+// GetUrlReports lists every report filed against one link.
+func (s *Server) GetUrlReports(ctx *gin.Context) {
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	reports, err := s.store.ListAbuseReportsByURLID(ctx, urlRecord.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	response := make([]AbuseReportResponse, len(reports))
+	for i, report := range reports {
+		response[i] = AbuseReportResponse{
+			ID:         report.ID,
+			Reason:     report.Reason,
+			Details:    report.Details.String,
+			Status:     report.Status,
+			CreatedAt:  timestampPtr(report.CreatedAt),
+			ResolvedAt: timestampPtr(report.ResolvedAt),
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code":   urlRecord.ShortCode,
+		"original_url": urlRecord.OriginalUrl,
+		"abuse_status": urlRecord.AbuseStatus.String,
+		"reports":      response,
+	})
+}
+
+// DismissUrlReports closes the open reports as unfounded, reinstates the link
+// if it was only suspended or matched the threat feed and drops the warning
+// page the reports put up. Banned links stay banned.
+func (s *Server) DismissUrlReports(ctx *gin.Context) {
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	dismissed, err := s.store.ResolveAbuseReports(ctx, db.ResolveAbuseReportsParams{
+		Status: reportStatusDismissed,
+		UrlID:  urlRecord.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss reports"})
+		return
+	}
+
+	reinstated, err := s.store.ReinstateURL(ctx, urlRecord.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reinstate URL"})
+		return
+	}
+
+	if urlRecord.InterstitialReason.String == interstitialReports {
+		_, err = s.store.SetURLInterstitial(ctx, db.SetURLInterstitialParams{ID: urlRecord.ID})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update URL"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code": urlRecord.ShortCode,
+		"dismissed":  dismissed,
+		"reinstated": reinstated > 0,
+	})
+}
+
+// BanUrl permanently takes the link down and closes its open reports.
+func (s *Server) BanUrl(ctx *gin.Context) {
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := s.store.BanURL(ctx, urlRecord.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban URL"})
+		return
+	}
+
+	actioned, err := s.store.ResolveAbuseReports(ctx, db.ResolveAbuseReportsParams{
+		Status: reportStatusActioned,
+		UrlID:  urlRecord.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code":   urlRecord.ShortCode,
+		"abuse_status": abuseStatusBanned,
+		"resolved":     actioned,
+	})
+}
+
+func (s *Server) ListBannedDomains(ctx *gin.Context) {
+	domains, err := s.store.ListBannedDomains(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve banned domains"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": domains})
+}
+
+// BanDomain bans a destination domain and its subdomains: new links to it are
+// refused and existing ones are banned.
+func (s *Server) BanDomain(ctx *gin.Context) {
+	var req BanDomainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	domain := utils.NormalizeDomain(req.Domain)
+	if domain == "" || !strings.Contains(domain, ".") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid domain"})
+		return
+	}
+
+	banned, err := s.store.CreateBannedDomain(ctx, db.CreateBannedDomainParams{
+		Domain: domain,
+		Reason: pgtype.Text{String: req.Reason, Valid: req.Reason != ""},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban domain"})
+		return
+	}
+	s.bannedDomains.Add(domain)
+
+	bannedUrls, err := s.store.BanURLsByDomain(ctx, domain)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban existing URLs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"domain":      banned,
+		"banned_urls": bannedUrls,
+	})
+}
+
+// UnbanDomain allows new links to the domain again. Links banned with it stay
+// banned.
+func (s *Server) UnbanDomain(ctx *gin.Context) {
+	domain := utils.NormalizeDomain(ctx.Param("domain"))
+
+	deleted, err := s.store.DeleteBannedDomain(ctx, domain)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban domain"})
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Domain is not banned"})
+		return
+	}
+	s.bannedDomains.Remove(domain)
+
+	ctx.JSON(http.StatusOK, gin.H{"domain": domain})
+}
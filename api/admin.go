@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CodeFilterResponse struct {
+	BuiltinReserved []string `json:"builtin_reserved"`
+	Reserved        []string `json:"reserved"`
+	Blocked         []string `json:"blocked"`
+}
+
+type CodeFilterWordRequest struct {
+	Word string `json:"word" binding:"required"`
+}
+
+const (
+	codeFilterListReserved = "reserved"
+	codeFilterListBlocked  = "blocked"
+)
+
+func (s *Server) codeFilterResponse() CodeFilterResponse {
+	lists := s.codeFilter.Lists()
+	return CodeFilterResponse{
+		BuiltinReserved: s.codeFilter.BuiltinReserved(),
+		Reserved:        lists.Reserved,
+		Blocked:         lists.Blocked,
+	}
+}
+
+func (s *Server) GetCodeFilter(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.codeFilterResponse())
+}
+
+func (s *Server) ReplaceCodeFilter(ctx *gin.Context) {
+	var req utils.CodeFilterLists
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := s.codeFilter.Replace(req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save code filter"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.codeFilterResponse())
+}
+
+func (s *Server) AddCodeFilterWord(ctx *gin.Context) {
+	var req CodeFilterWordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	var err error
+	switch ctx.Param("list") {
+	case codeFilterListReserved:
+		err = s.codeFilter.AddReserved(req.Word)
+	case codeFilterListBlocked:
+		err = s.codeFilter.AddBlocked(req.Word)
+	default:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Unknown list, expected reserved or blocked"})
+		return
+	}
+
+	if errors.Is(err, utils.ErrEmptyWord) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Word must not be empty"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save code filter"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.codeFilterResponse())
+}
+
+func (s *Server) RemoveCodeFilterWord(ctx *gin.Context) {
+	word := ctx.Param("word")
+
+	var err error
+	switch ctx.Param("list") {
+	case codeFilterListReserved:
+		err = s.codeFilter.RemoveReserved(word)
+	case codeFilterListBlocked:
+		err = s.codeFilter.RemoveBlocked(word)
+	default:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Unknown list, expected reserved or blocked"})
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save code filter"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.codeFilterResponse())
+}
+
+// GetShortCodeConflicts lists codes that only differ in case. They have to be
+// renamed before CASE_INSENSITIVE_CODES can be switched on.
+func (s *Server) GetShortCodeConflicts(ctx *gin.Context) {
+	conflicts, err := s.store.ListShortCodeCaseConflicts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list short code conflicts"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
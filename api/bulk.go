@@ -0,0 +1,580 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxBulkItems     = 5000
+	maxBulkBodyBytes = 10 << 20
+	bulkChunkSize    = 1000
+)
+
+type BulkCreateUrlResult struct {
+	Index        int        `json:"index"`
+	LongUrl      string     `json:"long_url"`
+	ShortUrl     string     `json:"short_url,omitempty"`
+	ActiveFrom   *time.Time `json:"active_from,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxClicks    *int64     `json:"max_clicks,omitempty"`
+	Interstitial string     `json:"interstitial,omitempty"`
+	RedirectType string     `json:"redirect_type,omitempty"`
+	ForwardQuery string     `json:"forward_query,omitempty"`
+	ForwardPath  bool       `json:"forward_path,omitempty"`
+	Warning      string     `json:"warning,omitempty"`
+	Reused       bool       `json:"reused,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+type BulkCreateUrlResponse struct {
+	Total     int                   `json:"total"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Results   []BulkCreateUrlResult `json:"results"`
+}
+
+type bulkItem struct {
+	req          CreateUrlRequest
+	canonicalUrl string
+	expiresAt    pgtype.Timestamp
+	maxClicks    pgtype.Int8
+	activeFrom   pgtype.Timestamp
+	interstitial pgtype.Text
+	redirectType pgtype.Text
+	forwardQuery pgtype.Text
+	warning      string
+	shortCode    string
+	parseErr     string
+}
+
+// CreateUrlsBulk shortens a JSON array or CSV upload of URLs in one request.
+// Every item gets its own result, and rows are inserted in batches instead of
+// one round trip per URL.
+func (s *Server) CreateUrlsBulk(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBulkBodyBytes)
+
+	items, err := parseBulkRequest(ctx)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body must not exceed %d MB", maxBulkBodyBytes>>20)})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(items) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No URLs provided"})
+		return
+	}
+
+	if len(items) > maxBulkItems {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d URLs per request", maxBulkItems)})
+		return
+	}
+
+	results := make([]BulkCreateUrlResult, len(items))
+	seenAliases := make(map[string]bool)
+	var pending []int
+
+	for i := range items {
+		item := &items[i]
+		results[i] = BulkCreateUrlResult{Index: i, LongUrl: item.req.LongUrl}
+
+		if item.parseErr != "" {
+			results[i].Error = item.parseErr
+			continue
+		}
+
+		if !isValidURL(item.req.LongUrl) {
+			results[i].Error = "Invalid URL format"
+			continue
+		}
+
+		if rejection := s.destination.Check(item.req.LongUrl); rejection != nil {
+			results[i].Error = rejection.Message
+			results[i].Reason = rejection.Reason
+			continue
+		}
+
+		if item.req.Password != "" {
+			results[i].Error = "Password-protected links must be created individually"
+			continue
+		}
+
+		if hasDeviceTargets(item.req) {
+			results[i].Error = "Device-targeted links must be created individually"
+			continue
+		}
+
+		if len(item.req.GeoRules) > 0 {
+			results[i].Error = "Geo-targeted links must be created individually"
+			continue
+		}
+
+		if len(item.req.Variants) > 0 {
+			results[i].Error = "Split-test links must be created individually"
+			continue
+		}
+
+		if len(item.req.ScheduleRules) > 0 {
+			results[i].Error = "Scheduled links must be created individually"
+			continue
+		}
+
+		switch item.req.CodeStyle {
+		case "":
+			item.req.CodeStyle = codeStyleRandom
+		case codeStyleRandom, codeStyleWords:
+		default:
+			results[i].Error = "code_style must be \"random\" or \"words\""
+			continue
+		}
+
+		canonicalUrl, err := s.canonicalize(item.req.LongUrl)
+		if err != nil {
+			results[i].Error = "Invalid URL format"
+			continue
+		}
+		item.canonicalUrl = canonicalUrl
+
+		expiresAt, err := resolveExpiry(item.req)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.expiresAt = expiresAt
+
+		activeFrom, err := resolveActiveFrom(item.req, expiresAt)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.activeFrom = activeFrom
+
+		maxClicks, err := resolveMaxClicks(item.req)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.maxClicks = maxClicks
+		item.interstitial = s.interstitialReason(item.req)
+
+		redirectType, warning, err := resolveRedirectType(item.req)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.redirectType = redirectType
+		item.warning = warning
+
+		forwardQuery, err := resolveForwardQuery(item.req)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.forwardQuery = forwardQuery
+
+		if item.req.Alias != "" {
+			if msg := s.aliasError(item.req.Alias); msg != "" {
+				results[i].Error = msg
+				continue
+			}
+			if seenAliases[item.req.Alias] {
+				results[i].Error = "Duplicate alias in request"
+				continue
+			}
+			seenAliases[item.req.Alias] = true
+			item.shortCode = item.req.Alias
+		}
+
+		pending = append(pending, i)
+	}
+
+	pending, followers, err := s.reuseBulkItems(ctx, items, pending, results)
+	if err != nil {
+		fmt.Println("Error looking up existing URLs:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up existing URLs"})
+		return
+	}
+
+	grown := false
+
+	for attempt := 0; attempt < maxRetries && len(pending) > 0; attempt++ {
+		used := make(map[string]bool, len(pending))
+		for _, i := range pending {
+			if items[i].req.Alias != "" {
+				used[items[i].shortCode] = true
+			}
+		}
+
+		needCodes := make(map[string][]int)
+		for _, i := range pending {
+			if items[i].req.Alias == "" {
+				style := items[i].req.CodeStyle
+				needCodes[style] = append(needCodes[style], i)
+			}
+		}
+
+		for style, indexes := range needCodes {
+			codes, err := s.generateUnusedCodes(ctx, s.generatorFor(style), len(indexes), used)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate short code"})
+				return
+			}
+			for n, i := range indexes {
+				items[i].shortCode = codes[n]
+			}
+		}
+
+		inserted, err := s.insertBulkItems(ctx, items, pending)
+		if err != nil {
+			fmt.Println("Error bulk inserting URLs:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short URLs"})
+			return
+		}
+
+		var retry []int
+		collided := make(map[string]int)
+		for _, i := range pending {
+			switch {
+			case inserted[items[i].shortCode]:
+				results[i].ShortUrl = s.config.BaseURL + "/" + items[i].shortCode
+				results[i].ActiveFrom = timestampPtr(items[i].activeFrom)
+				results[i].ExpiresAt = timestampPtr(items[i].expiresAt)
+				results[i].MaxClicks = int8Ptr(items[i].maxClicks)
+				results[i].Interstitial = items[i].interstitial.String
+				results[i].RedirectType = items[i].redirectType.String
+				results[i].ForwardQuery = items[i].forwardQuery.String
+				results[i].ForwardPath = items[i].req.ForwardPath
+				results[i].Warning = items[i].warning
+			case items[i].req.Alias != "":
+				results[i].Error = "Alias is already taken"
+			default:
+				retry = append(retry, i)
+				collided[items[i].req.CodeStyle]++
+			}
+		}
+		pending = retry
+
+		for style, indexes := range needCodes {
+			s.generatorFor(style).Observe(len(indexes), collided[style])
+		}
+		if attempt == maxRetries-1 && len(pending) > 0 && !grown {
+			for style := range collided {
+				if s.generatorFor(style).Grow() {
+					grown = true
+				}
+			}
+			if grown {
+				attempt = -1
+			}
+		}
+	}
+
+	for _, i := range pending {
+		results[i].Error = "Could not generate a unique short URL, please retry"
+	}
+
+	for follower, leader := range followers {
+		results[follower].ShortUrl = results[leader].ShortUrl
+		results[follower].ExpiresAt = results[leader].ExpiresAt
+		results[follower].Error = results[leader].Error
+		results[follower].Reused = results[leader].Error == ""
+	}
+
+	response := BulkCreateUrlResponse{Total: len(results), Results: results}
+	for _, r := range results {
+		if r.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// reuseBulkItems resolves items that may reuse an existing link for the same
+// destination. It fills in results for URLs already stored, and maps repeated
+// URLs within the request onto the first occurrence (the returned followers,
+// keyed by index). The remaining indexes still need to be inserted.
+func (s *Server) reuseBulkItems(ctx *gin.Context, items []bulkItem, pending []int, results []BulkCreateUrlResult) ([]int, map[int]int, error) {
+	var urls []string
+	for _, i := range pending {
+		if s.shouldReuseItem(items[i]) {
+			urls = append(urls, items[i].canonicalUrl)
+		}
+	}
+
+	followers := make(map[int]int)
+	if len(urls) == 0 {
+		return pending, followers, nil
+	}
+
+	existing := make(map[string]db.ListActiveURLsByCanonicalURLsRow, len(urls))
+	for start := 0; start < len(urls); start += bulkChunkSize {
+		end := min(start+bulkChunkSize, len(urls))
+
+		rows, err := s.store.ListActiveURLsByCanonicalURLs(ctx, urls[start:end])
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			existing[row.CanonicalUrl] = row
+		}
+	}
+
+	leaders := make(map[string]int)
+	var remaining []int
+	for _, i := range pending {
+		if !s.shouldReuseItem(items[i]) {
+			remaining = append(remaining, i)
+			continue
+		}
+
+		canonicalUrl := items[i].canonicalUrl
+		if row, ok := existing[canonicalUrl]; ok {
+			results[i].ShortUrl = s.config.BaseURL + "/" + row.ShortCode
+			results[i].ExpiresAt = timestampPtr(row.ExpiresAt)
+			results[i].Reused = true
+			continue
+		}
+
+		if leader, ok := leaders[canonicalUrl]; ok {
+			followers[i] = leader
+			continue
+		}
+
+		leaders[canonicalUrl] = i
+		remaining = append(remaining, i)
+	}
+
+	return remaining, followers, nil
+}
+
+// shouldReuseItem is shouldReuse for a bulk item; destinations the policy
+// flags always get a new link behind the interstitial.
+func (s *Server) shouldReuseItem(item bulkItem) bool {
+	return !item.interstitial.Valid && s.shouldReuse(item.req)
+}
+
+// generateUnusedCodes returns n codes from generator that are not in used,
+// adding them to it.
+func (s *Server) generateUnusedCodes(ctx context.Context, generator codeGenerator, n int, used map[string]bool) ([]string, error) {
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		generated, err := generator.Generate(ctx, n-len(codes))
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range generated {
+			if !used[code] {
+				used[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes, nil
+}
+
+// insertBulkItems inserts the given items in chunks and returns the set of
+// short codes that were actually written; the rest hit an existing code.
+func (s *Server) insertBulkItems(ctx *gin.Context, items []bulkItem, indexes []int) (map[string]bool, error) {
+	inserted := make(map[string]bool, len(indexes))
+
+	for start := 0; start < len(indexes); start += bulkChunkSize {
+		end := min(start+bulkChunkSize, len(indexes))
+
+		arg := db.CreateURLsBatchParams{
+			ShortCodes:          make([]string, 0, end-start),
+			OriginalUrls:        make([]string, 0, end-start),
+			ExpiresAts:          make([]pgtype.Timestamp, 0, end-start),
+			CanonicalUrls:       make([]string, 0, end-start),
+			MaxClicks:           make([]pgtype.Int8, 0, end-start),
+			ActiveFroms:         make([]pgtype.Timestamp, 0, end-start),
+			InterstitialReasons: make([]pgtype.Text, 0, end-start),
+			RedirectTypes:       make([]pgtype.Text, 0, end-start),
+			ForwardQueries:      make([]pgtype.Text, 0, end-start),
+			ForwardPaths:        make([]bool, 0, end-start),
+		}
+		for _, i := range indexes[start:end] {
+			arg.ShortCodes = append(arg.ShortCodes, items[i].shortCode)
+			arg.OriginalUrls = append(arg.OriginalUrls, items[i].req.LongUrl)
+			arg.ExpiresAts = append(arg.ExpiresAts, items[i].expiresAt)
+			arg.CanonicalUrls = append(arg.CanonicalUrls, items[i].canonicalUrl)
+			arg.MaxClicks = append(arg.MaxClicks, items[i].maxClicks)
+			arg.ActiveFroms = append(arg.ActiveFroms, items[i].activeFrom)
+			arg.InterstitialReasons = append(arg.InterstitialReasons, items[i].interstitial)
+			arg.RedirectTypes = append(arg.RedirectTypes, items[i].redirectType)
+			arg.ForwardQueries = append(arg.ForwardQueries, items[i].forwardQuery)
+			arg.ForwardPaths = append(arg.ForwardPaths, items[i].req.ForwardPath)
+		}
+
+		codes, err := s.store.CreateURLsBatch(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range codes {
+			inserted[code] = true
+		}
+	}
+
+	return inserted, nil
+}
+
+// parseBulkRequest accepts a JSON array body, a text/csv body, or a
+// multipart upload with the CSV in the "file" field.
+func parseBulkRequest(ctx *gin.Context) ([]bulkItem, error) {
+	contentType := ctx.ContentType()
+
+	switch contentType {
+	case "multipart/form-data":
+		fileHeader, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, bulkReadError(err, "Missing CSV file")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, errors.New("Cannot read CSV file")
+		}
+		defer file.Close()
+		return parseBulkCSV(file)
+	case "text/csv":
+		return parseBulkCSV(ctx.Request.Body)
+	}
+
+	var reqs []CreateUrlRequest
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&reqs); err != nil {
+		return nil, bulkReadError(err, "Invalid request")
+	}
+
+	items := make([]bulkItem, len(reqs))
+	for i, req := range reqs {
+		items[i] = bulkItem{req: req}
+		if req.LongUrl == "" {
+			items[i].parseErr = "long_url is required"
+		}
+	}
+	return items, nil
+}
+
+// bulkReadError keeps a body size error as is, so the handler can answer 413,
+// and replaces anything else with msg for the client.
+func bulkReadError(err error, msg string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// parseBulkCSV reads rows of long_url,alias,expires_at,ttl,max_clicks,
+// active_from,code_style,redirect_type,forward_query,forward_path. A header
+// row is optional; when present its column names decide the order.
+func parseBulkCSV(r io.Reader) ([]bulkItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, bulkReadError(err, "Invalid CSV")
+	}
+
+	columns := map[string]int{"long_url": 0, "alias": 1, "expires_at": 2, "ttl": 3, "max_clicks": 4, "active_from": 5, "code_style": 6, "redirect_type": 7,
+		"forward_query": 8, "forward_path": 9}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "long_url") {
+		columns = make(map[string]int)
+		for i, name := range records[0] {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		records = records[1:]
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	items := make([]bulkItem, len(records))
+	for i, record := range records {
+		item := bulkItem{req: CreateUrlRequest{
+			LongUrl:      field(record, "long_url"),
+			Alias:        field(record, "alias"),
+			TTL:          field(record, "ttl"),
+			CodeStyle:    field(record, "code_style"),
+			RedirectType: field(record, "redirect_type"),
+			ForwardQuery: field(record, "forward_query"),
+		}}
+
+		// Report the first problem in the row, in column order.
+		fail := func(msg string) {
+			if item.parseErr == "" {
+				item.parseErr = msg
+			}
+		}
+
+		if item.req.LongUrl == "" {
+			fail("long_url is required")
+		}
+
+		if raw := field(record, "expires_at"); raw != "" {
+			expiresAt, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				fail("expires_at must be an RFC 3339 timestamp")
+			} else {
+				item.req.ExpiresAt = &expiresAt
+			}
+		}
+
+		if raw := field(record, "max_clicks"); raw != "" {
+			maxClicks, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				fail("max_clicks must be a number")
+			} else {
+				item.req.MaxClicks = &maxClicks
+			}
+		}
+
+		if raw := field(record, "active_from"); raw != "" {
+			activeFrom, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				fail("active_from must be an RFC 3339 timestamp")
+			} else {
+				item.req.ActiveFrom = &activeFrom
+			}
+		}
+
+		if raw := field(record, "forward_path"); raw != "" {
+			forwardPath, err := strconv.ParseBool(raw)
+			if err != nil {
+				fail("forward_path must be true or false")
+			} else {
+				item.req.ForwardPath = forwardPath
+			}
+		}
+
+		items[i] = item
+	}
+
+	return items, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseBulkCSVKeepsFirstError(t *testing.T) {
+	tests := []struct {
+		name string
+		row  string
+		want string
+	}{
+		{"valid row", "https://example.com,,2030-01-01T00:00:00Z,,5", ""},
+		{"missing url", ",,not-a-date,,x", "long_url is required"},
+		{"bad expiry before bad clicks", "https://example.com,,not-a-date,,x", "expires_at must be an RFC 3339 timestamp"},
+		{"bad clicks before bad start", "https://example.com,,,,x,not-a-date", "max_clicks must be a number"},
+		{"bad start before bad path", "https://example.com,,,,,not-a-date,,,,maybe", "active_from must be an RFC 3339 timestamp"},
+		{"bad path", "https://example.com,,,,,,,,,maybe", "forward_path must be true or false"},
+	}
+
+	for _, tt := range tests {
+		items, err := parseBulkCSV(strings.NewReader(tt.row))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("%s: got %d items, want 1", tt.name, len(items))
+		}
+		if items[0].parseErr != tt.want {
+			t.Errorf("%s: got error %q, want %q", tt.name, items[0].parseErr, tt.want)
+		}
+	}
+}
+
+func TestCreateUrlsBulkLimitsBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	csvBody := "long_url\n" + strings.Repeat("https://example.com/"+strings.Repeat("a", 80)+"\n", maxBulkBodyBytes/100+1)
+
+	var multipartBody bytes.Buffer
+	writer := multipart.NewWriter(&multipartBody)
+	part, err := writer.CreateFormFile("file", "urls.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(csvBody))
+	writer.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"csv", "text/csv", []byte(csvBody)},
+		{"multipart", writer.FormDataContentType(), multipartBody.Bytes()},
+		{"json", "application/json", []byte(`[{"long_url": "` + strings.Repeat("a", maxBulkBodyBytes) + `"}]`)},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/url/shorten/bulk", bytes.NewReader(tt.body))
+		ctx.Request.Header.Set("Content-Type", tt.contentType)
+
+		(&Server{}).CreateUrlsBulk(ctx)
+		if recorder.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: got status %d, want %d: %s", tt.name, recorder.Code, http.StatusRequestEntityTooLarge, recorder.Body.String())
+		}
+	}
+}
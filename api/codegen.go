@@ -0,0 +1,426 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"url-shortener/utils"
+
+	db "url-shortener/db/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	codeStrategyRandom   = "random"
+	codeStrategySequence = "sequence"
+
+	codeStyleRandom = "random"
+	codeStyleWords  = "words"
+
+	// maxWordCodeDigits caps how far the numeric suffix of word codes grows.
+	maxWordCodeDigits = 6
+
+	defaultMaxCodeLength   = 12
+	defaultGrowthThreshold = 0.1
+
+	// collisionSampleSize is how many inserts are observed before the
+	// collision rate is trusted enough to grow the code length.
+	collisionSampleSize = 20
+	collisionWindowSize = 1000
+
+	codeLengthSaveTimeout = 5 * time.Second
+)
+
+// codeGenerator produces candidate short codes. Callers still insert under the
+// unique index, since aliases and codes from other strategies share the table.
+type codeGenerator interface {
+	Generate(ctx context.Context, n int) ([]string, error)
+	// Observe reports how many generated codes were tried and how many of
+	// them collided with existing ones.
+	Observe(tried, collided int)
+	// Grow lengthens future codes, returning false if it cannot.
+	Grow() bool
+}
+
+func newCodeGenerator(config *utils.Config, store db.Store, filter *utils.CodeFilter) (codeGenerator, error) {
+	alphabet := utils.Base62
+	if config.CaseInsensitiveCodes {
+		alphabet = utils.Base36
+	}
+	if config.CodeAlphabet != "" {
+		var err error
+		alphabet, err = utils.NewAlphabet(config.CodeAlphabet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CODE_ALPHABET: %w", err)
+		}
+	}
+	if config.CaseInsensitiveCodes && alphabet.HasCaseVariants() {
+		return nil, fmt.Errorf("CODE_ALPHABET must be single-case when CASE_INSENSITIVE_CODES is set")
+	}
+
+	length := config.CodeLength
+	if length <= 0 {
+		length = utils.DefaultLength
+	}
+
+	maxLength := config.CodeMaxLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxCodeLength
+	}
+	maxLength = min(max(maxLength, length), utils.MaxShortCodeLength)
+
+	switch config.CodeStrategy {
+	case "", codeStrategyRandom:
+		threshold := config.CodeGrowthThreshold
+		if threshold <= 0 {
+			threshold = defaultGrowthThreshold
+		}
+		length, err := loadCodeLength(store, codeStrategyRandom, length, maxLength)
+		if err != nil {
+			return nil, err
+		}
+		return &randomCodeGenerator{
+			store:     store,
+			filter:    filter,
+			alphabet:  alphabet,
+			length:    length,
+			maxLength: maxLength,
+			threshold: threshold,
+		}, nil
+	case codeStrategySequence:
+		if config.CodePermutationKey == "" {
+			return nil, fmt.Errorf("CODE_PERMUTATION_KEY is required for the %q code strategy", codeStrategySequence)
+		}
+
+		length, err := loadCodeLength(store, codeStrategySequence, length, maxLength)
+		if err != nil {
+			return nil, err
+		}
+		key := []byte(config.CodePermutationKey)
+		permutation, err := sequencePermutation(alphabet, key, length)
+		if err != nil {
+			return nil, err
+		}
+		return &sequenceCodeGenerator{
+			store:       store,
+			filter:      filter,
+			alphabet:    alphabet,
+			key:         key,
+			maxLength:   maxLength,
+			length:      length,
+			permutation: permutation,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown CODE_STRATEGY %q", config.CodeStrategy)
+	}
+}
+
+// loadCodeLength returns the length a generator had grown to before the last
+// restart, or length if it never grew, capped at maxLength.
+func loadCodeLength(store db.Store, generator string, length, maxLength int) (int, error) {
+	stored, err := store.GetCodeLength(context.Background(), generator)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("cannot load %s code length: %w", generator, err)
+	}
+	return min(max(int(stored), length), maxLength), nil
+}
+
+// saveCodeLength records a grown length. Generators call it while holding
+// their lock, so it gives up quickly; the next growth stores the length again.
+func saveCodeLength(store db.Store, generator string, length int) {
+	ctx, cancel := context.WithTimeout(context.Background(), codeLengthSaveTimeout)
+	defer cancel()
+
+	err := store.RaiseCodeLength(ctx, db.RaiseCodeLengthParams{
+		Generator: generator,
+		Length:    int32(length),
+	})
+	if err != nil {
+		log.Printf("cannot save %s code length: %v", generator, err)
+	}
+}
+
+// randomCodeGenerator draws codes uniformly from the alphabet and lengthens
+// them once the observed collision rate passes the threshold, so a filling
+// keyspace costs one more character instead of failed requests.
+type randomCodeGenerator struct {
+	store     db.Store
+	filter    *utils.CodeFilter
+	alphabet  *utils.Alphabet
+	maxLength int
+	threshold float64
+
+	mu       sync.Mutex
+	length   int
+	tried    int
+	collided int
+}
+
+func (g *randomCodeGenerator) Generate(_ context.Context, n int) ([]string, error) {
+	g.mu.Lock()
+	length := g.length
+	g.mu.Unlock()
+
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		code, err := g.alphabet.Generate(length)
+		if err != nil {
+			return nil, err
+		}
+		if !g.filter.Allowed(code) {
+			continue
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func (g *randomCodeGenerator) Observe(tried, collided int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.tried += tried
+	g.collided += collided
+
+	if g.tried >= collisionSampleSize && float64(g.collided)/float64(g.tried) > g.threshold {
+		g.growLocked()
+		return
+	}
+
+	if g.tried >= collisionWindowSize {
+		g.tried, g.collided = 0, 0
+	}
+}
+
+func (g *randomCodeGenerator) Grow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.growLocked()
+}
+
+func (g *randomCodeGenerator) growLocked() bool {
+	g.tried, g.collided = 0, 0
+
+	if g.length >= g.maxLength {
+		return false
+	}
+
+	g.length++
+	log.Printf("short code collision rate too high, growing code length to %d", g.length)
+	saveCodeLength(g.store, codeStrategyRandom, g.length)
+	return true
+}
+
+// sequenceCodeGenerator draws IDs from short_code_seq and runs them through a
+// keyed Feistel permutation before encoding, so codes never repeat but cannot
+// be enumerated in creation order. Once the IDs outgrow the keyspace of the
+// current length, codes move on to the next length.
+type sequenceCodeGenerator struct {
+	store     db.Store
+	filter    *utils.CodeFilter
+	alphabet  *utils.Alphabet
+	key       []byte
+	maxLength int
+
+	mu          sync.Mutex
+	length      int
+	permutation *utils.FeistelPermutation
+}
+
+// sequencePermutation builds the permutation for codes of one length. It
+// works on an even number of bits, so it uses the largest domain whose values
+// still fit in a code of that length.
+func sequencePermutation(alphabet *utils.Alphabet, key []byte, length int) (*utils.FeistelPermutation, error) {
+	bits := min(uint(alphabet.Bits(length)), 62) &^ 1
+	return utils.NewFeistelPermutation(key, bits)
+}
+
+func (g *sequenceCodeGenerator) Generate(ctx context.Context, n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		ids, err := g.store.NextShortCodeIDs(ctx, int32(n-len(codes)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			permutation, length, err := g.rangeFor(uint64(id))
+			if err != nil {
+				return nil, err
+			}
+
+			code := g.alphabet.EncodePadded(permutation.Permute(uint64(id)), length)
+			if !g.filter.Allowed(code) {
+				continue
+			}
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+// rangeFor returns the permutation and code length to encode id with, growing
+// past every length whose keyspace id no longer fits in. Each length is a
+// keyspace of its own and IDs never repeat, so codes stay unique across
+// growth and restarts.
+func (g *sequenceCodeGenerator) rangeFor(id uint64) (*utils.FeistelPermutation, int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for id >= g.permutation.Max() {
+		if !g.growLocked() {
+			return nil, 0, fmt.Errorf("short code sequence exhausted at %d", id)
+		}
+	}
+	return g.permutation, g.length, nil
+}
+
+// Sequence codes only collide with aliases or codes from another strategy,
+// which says nothing about how full the keyspace is.
+func (g *sequenceCodeGenerator) Observe(tried, collided int) {}
+
+func (g *sequenceCodeGenerator) Grow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.growLocked()
+}
+
+func (g *sequenceCodeGenerator) growLocked() bool {
+	if g.length >= g.maxLength {
+		return false
+	}
+
+	permutation, err := sequencePermutation(g.alphabet, g.key, g.length+1)
+	if err != nil {
+		log.Printf("cannot grow short code sequence to length %d: %v", g.length+1, err)
+		return false
+	}
+
+	g.length++
+	g.permutation = permutation
+	log.Printf("short code sequence moved on to length %d", g.length)
+	saveCodeLength(g.store, codeStrategySequence, g.length)
+	return true
+}
+
+// wordCodeGenerator builds codes like "brave-otter-42" that are easy to read
+// out loud. When collisions pile up it adds a digit to the suffix, the same
+// way randomCodeGenerator adds a character.
+type wordCodeGenerator struct {
+	store     db.Store
+	filter    *utils.CodeFilter
+	words     int
+	maxDigits int
+	threshold float64
+
+	mu       sync.Mutex
+	digits   int
+	tried    int
+	collided int
+}
+
+func newWordCodeGenerator(config *utils.Config, store db.Store, filter *utils.CodeFilter) (*wordCodeGenerator, error) {
+	words := config.WordCodeWords
+	if words <= 0 {
+		words = utils.DefaultWordCodeWords
+	}
+
+	// Unset or negative means the default; 0 means no numeric suffix.
+	digits := config.WordCodeDigits
+	if digits < 0 {
+		digits = utils.DefaultWordCodeDigits
+	}
+
+	// Fail at startup rather than on the first request if the settings cannot
+	// produce a valid code.
+	if _, err := utils.GenerateWordCode(words, digits); err != nil {
+		return nil, fmt.Errorf("invalid WORD_CODE_WORDS/WORD_CODE_DIGITS: %w", err)
+	}
+
+	// Stop growing the suffix before codes could outgrow the short_code column.
+	maxDigits := max(digits, maxWordCodeDigits)
+	for maxDigits > digits && utils.WordCodeMaxLength(words, maxDigits) > utils.MaxShortCodeLength {
+		maxDigits--
+	}
+
+	threshold := config.CodeGrowthThreshold
+	if threshold <= 0 {
+		threshold = defaultGrowthThreshold
+	}
+
+	digits, err := loadCodeLength(store, codeStyleWords, digits, maxDigits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wordCodeGenerator{
+		store:     store,
+		filter:    filter,
+		words:     words,
+		digits:    digits,
+		maxDigits: maxDigits,
+		threshold: threshold,
+	}, nil
+}
+
+func (g *wordCodeGenerator) Generate(_ context.Context, n int) ([]string, error) {
+	g.mu.Lock()
+	digits := g.digits
+	g.mu.Unlock()
+
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		code, err := utils.GenerateWordCode(g.words, digits)
+		if err != nil {
+			return nil, err
+		}
+		if !g.filter.Allowed(code) {
+			continue
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func (g *wordCodeGenerator) Observe(tried, collided int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.tried += tried
+	g.collided += collided
+
+	if g.tried >= collisionSampleSize && float64(g.collided)/float64(g.tried) > g.threshold {
+		g.growLocked()
+		return
+	}
+
+	if g.tried >= collisionWindowSize {
+		g.tried, g.collided = 0, 0
+	}
+}
+
+func (g *wordCodeGenerator) Grow() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.growLocked()
+}
+
+func (g *wordCodeGenerator) growLocked() bool {
+	g.tried, g.collided = 0, 0
+
+	if g.digits >= g.maxDigits {
+		return false
+	}
+
+	g.digits++
+	log.Printf("word code collision rate too high, growing suffix to %d digits", g.digits)
+	saveCodeLength(g.store, codeStyleWords, g.digits)
+	return true
+}
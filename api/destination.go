@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newDestinationPolicy assembles the checks every new destination URL has to
+// pass. Cheap host checks run first, the threat feed last.
+func newDestinationPolicy(config *utils.Config, threatFeed *utils.ThreatFeed, bannedDomains *utils.BannedDomainCheck) *utils.DestinationPolicy {
+	checks := []utils.DestinationCheck{
+		utils.NewSelfReferenceCheck(config.BaseURL),
+		utils.NewDomainListCheck(config.DestinationDenyDomains, config.DestinationAllowDomains),
+		bannedDomains,
+	}
+	// Destinations on the warn list, and other shorteners when they are
+	// allowed, get the warning interstitial instead of being refused.
+	warnings := []utils.DestinationCheck{
+		utils.NewDomainListCheck(config.DestinationWarnDomains, ""),
+	}
+
+	shorteners := utils.NewShortenerCheck(config.ExtraURLShorteners)
+	if config.AllowURLShorteners {
+		warnings = append(warnings, shorteners)
+	} else {
+		checks = append(checks, shorteners)
+	}
+	if threatFeed != nil {
+		checks = append(checks, threatFeed)
+	}
+	return utils.NewDestinationPolicy(checks...).WithWarnings(warnings...)
+}
+
+// checkDestination writes a 400 response with the rejection reason when the
+// policy refuses longUrl.
+func (s *Server) checkDestination(ctx *gin.Context, longUrl string) bool {
+	rejection := s.destination.Check(longUrl)
+	if rejection == nil {
+		return true
+	}
+
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": rejection.Message, "reason": rejection.Reason})
+	return false
+}
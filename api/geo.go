@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxGeoRules = 50
+
+type SetGeoRulesRequest struct {
+	Rules map[string]string `json:"rules"`
+}
+
+type GeoRuleResponse struct {
+	CountryCode string `json:"country_code"`
+	Destination string `json:"destination"`
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveGeoRules validates country to destination rules and upper-cases the
+// country codes. Destinations pass the same policy as long_url. Rules need
+// COUNTRY_HEADER, without it every visitor would get the default. It writes
+// a 400 response and returns false when a rule is refused.
+func (s *Server) resolveGeoRules(ctx *gin.Context, rules map[string]string) (map[string]string, bool) {
+	if len(rules) > 0 && s.config.CountryHeader == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Geo rules are not available, COUNTRY_HEADER is not configured"})
+		return nil, false
+	}
+	if len(rules) > maxGeoRules {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d geo rules per link", maxGeoRules)})
+		return nil, false
+	}
+
+	resolved := make(map[string]string, len(rules))
+	for country, destination := range rules {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !isCountryCode(country) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid country code %q, expected ISO 3166-1 alpha-2 such as \"DE\"", country)})
+			return nil, false
+		}
+		if _, duplicate := resolved[country]; duplicate {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Duplicate geo rule for %s", country)})
+			return nil, false
+		}
+		if !isValidURL(destination) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid destination for %s", country)})
+			return nil, false
+		}
+		if !s.checkDestination(ctx, destination) {
+			return nil, false
+		}
+		resolved[country] = destination
+	}
+	return resolved, true
+}
+
+// geoDestination returns the destination of the rule for the visitor's
+// country, or original_url when no rule matches. The country comes from the
+// same lookup that fills clicks.country.
+func (s *Server) geoDestination(ctx *gin.Context, urlRecord db.Url) string {
+	country := utils.GetCountry(ctx, s.config.CountryHeader)
+	if country == "" {
+		return urlRecord.OriginalUrl
+	}
+
+	destination, err := s.store.GetGeoRuleDestination(ctx, db.GetGeoRuleDestinationParams{
+		UrlID:       urlRecord.ID,
+		CountryCode: country,
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Println("failed to look up geo rule:", err)
+		}
+		return urlRecord.OriginalUrl
+	}
+	return destination
+}
+
+// GetUrlGeoRules lists the country rules of a link.
+func (s *Server) GetUrlGeoRules(ctx *gin.Context) {
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	rules, err := s.store.ListGeoRulesByURLID(ctx, urlRecord.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve geo rules"})
+		return
+	}
+
+	response := make([]GeoRuleResponse, len(rules))
+	for i, rule := range rules {
+		response[i] = GeoRuleResponse{CountryCode: rule.CountryCode, Destination: rule.Destination}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code": urlRecord.ShortCode,
+		"default":    urlRecord.OriginalUrl,
+		"rules":      response,
+	})
+}
+
+// SetUrlGeoRules replaces the country rules of a link. An empty rules object
+// removes them all.
+func (s *Server) SetUrlGeoRules(ctx *gin.Context) {
+	var req SetGeoRulesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	if len(req.Rules) > 0 {
+		if urlRecord.VariantMode.Valid || urlRecord.ScheduleRouted {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Geo rules cannot be combined with split tests or schedule rules"})
+			return
+		}
+		if isPermanentRedirect(urlRecord.RedirectType.String) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Permanent redirects cannot be combined with geo rules"})
+			return
+		}
+	}
+
+	rules, ok := s.resolveGeoRules(ctx, req.Rules)
+	if !ok {
+		return
+	}
+
+	err := s.store.SetGeoRulesTx(ctx, db.SetGeoRulesTxParams{UrlID: urlRecord.ID, Rules: rules})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store geo rules"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code": urlRecord.ShortCode,
+		"default":    urlRecord.OriginalUrl,
+		"rules":      rules,
+	})
+}
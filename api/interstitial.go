@@ -0,0 +1,192 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	interstitialManual  = "manual"
+	interstitialPolicy  = "policy"
+	interstitialReports = "reports"
+
+	defaultInterstitialThreshold = 2
+
+	// interstitialConfirmParam carries the token of the continue button.
+	interstitialConfirmParam = "confirm"
+	// interstitialConfirmTTL is how long a continue link works, so a shared
+	// one cannot be used to skip the warning for good.
+	interstitialConfirmTTL = 10 * time.Minute
+)
+
+var interstitialWarnings = map[string]string{
+	interstitialManual:  "The creator of this link asked us to show you where it leads before you continue.",
+	interstitialPolicy:  "This destination has been flagged as potentially risky. Only continue if you trust it.",
+	interstitialReports: "Other visitors have reported this link and it is under review. Only continue if you trust the destination.",
+}
+
+// interstitialPage shows where this visitor will be sent. Destinations lists
+// every possible one instead when it is not known yet, for split tests that
+// pick a variant at random on each visit.
+type interstitialPage struct {
+	ShortCode    string
+	Destination  string
+	Destinations []string
+	Warning      string
+	ContinueUrl  string
+}
+
+type SetInterstitialRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+func (s *Server) interstitialThreshold() int64 {
+	if s.config.InterstitialThreshold > 0 {
+		return int64(s.config.InterstitialThreshold)
+	}
+	return defaultInterstitialThreshold
+}
+
+// interstitialReason decides whether a new link starts behind the warning
+// page: on request, or when the destination policy flags any of its
+// destinations.
+func (s *Server) interstitialReason(req CreateUrlRequest) pgtype.Text {
+	if req.Interstitial {
+		return pgtype.Text{String: interstitialManual, Valid: true}
+	}
+	destinations := []string{req.LongUrl, req.IosUrl, req.AndroidUrl, req.DesktopUrl}
+	for _, destination := range req.GeoRules {
+		destinations = append(destinations, destination)
+	}
+	for _, variant := range req.Variants {
+		destinations = append(destinations, variant.Url)
+	}
+	for _, rule := range req.ScheduleRules {
+		destinations = append(destinations, rule.Url)
+	}
+	for _, destination := range destinations {
+		if destination != "" && s.destination.Warning(destination) != nil {
+			return pgtype.Text{String: interstitialPolicy, Valid: true}
+		}
+	}
+	return pgtype.Text{}
+}
+
+// needsInterstitial reports whether the visitor has to see the warning page
+// first. The continue button links back with a signed, short-lived confirm
+// token, and only that request counts as a click.
+func (s *Server) needsInterstitial(ctx *gin.Context, urlRecord db.Url) bool {
+	return urlRecord.InterstitialReason.Valid && !s.validConfirmToken(urlRecord, ctx.Query(interstitialConfirmParam))
+}
+
+func (s *Server) signConfirm(urlRecord db.Url, expires int64) string {
+	mac := hmac.New(sha256.New, s.cookieSecret)
+	fmt.Fprintf(mac, "confirm|%s|%d", urlRecord.ShortCode, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) confirmToken(urlRecord db.Url) string {
+	expires := time.Now().Add(interstitialConfirmTTL).Unix()
+	return strconv.FormatInt(expires, 10) + "." + s.signConfirm(urlRecord, expires)
+}
+
+func (s *Server) validConfirmToken(urlRecord db.Url, token string) bool {
+	rawExpires, signature, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.signConfirm(urlRecord, expires)))
+}
+
+func (s *Server) renderInterstitial(ctx *gin.Context, urlRecord db.Url) {
+	warning, ok := interstitialWarnings[urlRecord.InterstitialReason.String]
+	if !ok {
+		warning = interstitialWarnings[interstitialPolicy]
+	}
+
+	page := interstitialPage{
+		ShortCode:   urlRecord.ShortCode,
+		Warning:     warning,
+		ContinueUrl: withConfirm(followUpPath(ctx, urlRecord), s.confirmToken(urlRecord)),
+	}
+
+	if urlRecord.VariantMode.String == variantModeRandom {
+		variants, err := s.store.ListVariantsByURLID(ctx, urlRecord.ID)
+		if err != nil {
+			log.Println("failed to load variants:", err)
+		}
+		for _, variant := range variants {
+			page.Destinations = append(page.Destinations, withPassthrough(ctx, urlRecord, variant.Destination))
+		}
+	}
+
+	if len(page.Destinations) > 0 {
+		page.Destination = page.Destinations[0]
+	} else {
+		// In sticky mode this assigns the variant that continuing serves.
+		destination, _ := s.ruleDestination(ctx, urlRecord)
+		page.Destination, _ = s.visitorDestination(ctx, urlRecord, destination)
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.HTML(http.StatusOK, "interstitial.html", page)
+}
+
+// withConfirm marks target as the continue button's request.
+func withConfirm(target, token string) string {
+	separator := "?"
+	if strings.Contains(target, "?") {
+		separator = "&"
+	}
+	return target + separator + interstitialConfirmParam + "=" + token
+}
+
+// SetUrlInterstitial turns the warning page on or off for a link by hand.
+func (s *Server) SetUrlInterstitial(ctx *gin.Context) {
+	var req SetInterstitialRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	var reason pgtype.Text
+	if *req.Enabled {
+		reason = pgtype.Text{String: interstitialManual, Valid: true}
+	}
+
+	_, err := s.store.SetURLInterstitial(ctx, db.SetURLInterstitialParams{
+		ID:                 urlRecord.ID,
+		InterstitialReason: reason,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update URL"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code":   urlRecord.ShortCode,
+		"interstitial": reason.String,
+	})
+}
@@ -2,27 +2,35 @@ package api
 
 import (
 	"net/http"
+	"url-shortener/worker"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type MetricsResponse struct {
 	TotalURLs   int64    `json:"total_urls"`
+	ExpiredURLs int64    `json:"expired_urls"`
 	TotalClicks int64    `json:"total_clicks"`
 	URLsToday   int64    `json:"urls_created_today"`
 	ClicksToday int64    `json:"clicks_today"`
 	TopURLs     []TopURL `json:"top_urls"`
+
+	Sweeper *worker.SweeperStats `json:"sweeper,omitempty"`
 }
 
 type TopURL struct {
-	ShortCode   string `json:"short_code"`
-	OriginalURL string `json:"original_url"`
-	Clicks      int64  `json:"clicks"`
-	TinyUrl     string `json:"tiny_url"`
+	ShortCode   string           `json:"short_code"`
+	OriginalURL string           `json:"original_url"`
+	Clicks      int64            `json:"clicks"`
+	TinyUrl     string           `json:"tiny_url"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	IsExpired   bool             `json:"is_expired"`
 }
 
 func (s *Server) GetMetrics(ctx *gin.Context) {
-	totalURLs, _ := s.store.CountURLs(ctx)
+	totalURLs, _ := s.store.CountURLs(ctx, pgtype.Text{})
+	expiredURLs, _ := s.store.CountExpiredURLs(ctx)
 	totalClicks, _ := s.store.CountAllClicks(ctx)
 	urlsToday, _ := s.store.CountURLsToday(ctx)
 	clicksToday, _ := s.store.CountClicksToday(ctx)
@@ -35,14 +43,24 @@ func (s *Server) GetMetrics(ctx *gin.Context) {
 			OriginalURL: u.OriginalUrl,
 			Clicks:      u.ClickCount.Int64,
 			TinyUrl:     s.config.BaseURL + "/" + u.ShortCode,
+			ExpiresAt:   u.ExpiresAt,
+			IsExpired:   isExpired(u.ExpiresAt),
 		}
 	}
 
-	ctx.JSON(http.StatusOK, MetricsResponse{
+	response := MetricsResponse{
 		TotalURLs:   totalURLs,
+		ExpiredURLs: expiredURLs,
 		TotalClicks: totalClicks,
 		URLsToday:   urlsToday,
 		ClicksToday: clicksToday,
 		TopURLs:     topURLResponses,
-	})
+	}
+
+	if s.sweeper != nil {
+		stats := s.sweeper.Stats()
+		response.Sweeper = &stats
+	}
+
+	ctx.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Query passthrough modes. Both add the visitor's parameters to the
+// destination and differ only when a name is on both sides: with merge the
+// link's value is kept, with override the visitor's replaces it.
+const (
+	forwardQueryMerge    = "merge"
+	forwardQueryOverride = "override"
+)
+
+// reservedQueryParams are this service's own markers and are never passed
+// on to the destination.
+var reservedQueryParams = []string{interstitialConfirmParam, clickSourceParam}
+
+func resolveForwardQuery(req CreateUrlRequest) (pgtype.Text, error) {
+	switch req.ForwardQuery {
+	case "":
+		return pgtype.Text{}, nil
+	case forwardQueryMerge, forwardQueryOverride:
+		return pgtype.Text{String: req.ForwardQuery, Valid: true}, nil
+	}
+	return pgtype.Text{}, errors.New("forward_query must be merge or override")
+}
+
+// requestPath returns what followed the short code, "/api/v2" for
+// /docs/api/v2, or "" when nothing but a trailing slash did.
+func requestPath(ctx *gin.Context) string {
+	rest := ctx.Param("rest")
+	if rest == "/" {
+		return ""
+	}
+	return rest
+}
+
+// forwardedQuery returns the visitor's query parameters to pass on.
+func forwardedQuery(ctx *gin.Context) url.Values {
+	query := ctx.Request.URL.Query()
+	for _, name := range reservedQueryParams {
+		query.Del(name)
+	}
+	return query
+}
+
+// withPassthrough adds the request path and query to destination, as far as
+// the link passes them through.
+func withPassthrough(ctx *gin.Context, urlRecord db.Url, destination string) string {
+	rest := ""
+	if urlRecord.ForwardPath {
+		rest = requestPath(ctx)
+	}
+	var query url.Values
+	if urlRecord.ForwardQuery.Valid {
+		query = forwardedQuery(ctx)
+	}
+	if rest == "" && len(query) == 0 {
+		return destination
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+
+	if rest != "" {
+		// Cleaning resolves "..", so the path can only go deeper than the
+		// destination's, never above it.
+		cleaned := path.Clean(rest)
+		if strings.HasSuffix(rest, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + (&url.URL{Path: cleaned}).EscapedPath()
+		if decoded, err := url.PathUnescape(rawPath); err == nil {
+			u.Path, u.RawPath = decoded, rawPath
+		}
+	}
+
+	if len(query) > 0 {
+		u.RawQuery = mergeQuery(u.RawQuery, query, urlRecord.ForwardQuery.String == forwardQueryOverride)
+	}
+
+	return u.String()
+}
+
+// mergeQuery adds incoming to the raw destination query. The destination's
+// parameters keep their order and encoding; on a name clash the incoming
+// values replace them only when override is set.
+func mergeQuery(raw string, incoming url.Values, override bool) string {
+	var pairs []string
+	existing := make(map[string]bool)
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if override && incoming.Has(name) {
+			continue
+		}
+		existing[name] = true
+		pairs = append(pairs, pair)
+	}
+
+	added := url.Values{}
+	for name, values := range incoming {
+		if !existing[name] {
+			added[name] = values
+		}
+	}
+	if encoded := added.Encode(); encoded != "" {
+		pairs = append(pairs, encoded)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// followUpPath is the short URL the visitor asked for, minus the confirm
+// marker, for the unlock form and the warning page to send them back to. It
+// keeps the source marker, and the path and query when the link passes
+// them through.
+func followUpPath(ctx *gin.Context, urlRecord db.Url) string {
+	target := "/" + urlRecord.ShortCode
+	if urlRecord.ForwardPath {
+		target += (&url.URL{Path: requestPath(ctx)}).EscapedPath()
+	}
+
+	if !urlRecord.ForwardQuery.Valid {
+		return withClickSource(target, clickSource(ctx))
+	}
+
+	query := ctx.Request.URL.Query()
+	query.Del(interstitialConfirmParam)
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	return target
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMergeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		incoming url.Values
+		override bool
+		want     string
+	}{
+		{"empty destination query", "", url.Values{"a": {"1"}}, false, "a=1"},
+		{"nothing incoming", "a=1&b=2", url.Values{}, false, "a=1&b=2"},
+		{"adds new names", "a=1", url.Values{"b": {"2"}}, false, "a=1&b=2"},
+		{"merge keeps the link's value", "a=1&b=2", url.Values{"a": {"9"}}, false, "a=1&b=2"},
+		{"override replaces the link's value", "a=1&b=2", url.Values{"a": {"9"}}, true, "b=2&a=9"},
+		{"override replaces repeated names", "a=1&a=2&b=3", url.Values{"a": {"9"}}, true, "b=3&a=9"},
+		{"keeps destination encoding", "q=a%20b&x", url.Values{"y": {"c d"}}, false, "q=a%20b&x&y=c+d"},
+		{"matches escaped names", "a%5B%5D=1", url.Values{"a[]": {"2"}}, true, "a%5B%5D=2"},
+	}
+
+	for _, tt := range tests {
+		if got := mergeQuery(tt.raw, tt.incoming, tt.override); got != tt.want {
+			t.Errorf("%s: mergeQuery(%q) = %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestWithPassthrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	merge := pgtype.Text{String: forwardQueryMerge, Valid: true}
+	override := pgtype.Text{String: forwardQueryOverride, Valid: true}
+
+	tests := []struct {
+		name         string
+		target       string
+		rest         string
+		forwardPath  bool
+		forwardQuery pgtype.Text
+		destination  string
+		want         string
+	}{
+		{"no passthrough", "/docs/api?a=1", "/api", false, pgtype.Text{}, "https://ex.com/d?a=0", "https://ex.com/d?a=0"},
+		{"path only", "/docs/api/v2?a=1", "/api/v2", true, pgtype.Text{}, "https://ex.com/d/", "https://ex.com/d/api/v2"},
+		{"path keeps trailing slash", "/docs/api/", "/api/", true, pgtype.Text{}, "https://ex.com/d", "https://ex.com/d/api/"},
+		{"path cannot climb", "/docs/../../etc", "/../../etc", true, pgtype.Text{}, "https://ex.com/d", "https://ex.com/d/etc"},
+		{"path keeps escapes", "/docs/a%2Fb", "/a/b", true, pgtype.Text{}, "https://ex.com/x%20y", "https://ex.com/x%20y/a/b"},
+		{"query merge", "/docs?a=1&b=2", "", false, merge, "https://ex.com/?a=0", "https://ex.com/?a=0&b=2"},
+		{"query override", "/docs?a=1&b=2", "", false, override, "https://ex.com/?a=0&c=3", "https://ex.com/?c=3&a=1&b=2"},
+		{"reserved params stay", "/docs?src=qr&confirm=x&a=1", "", false, merge, "https://ex.com/", "https://ex.com/?a=1"},
+		{"path and query", "/docs/p?a=1", "/p", true, merge, "https://ex.com/d?z=0", "https://ex.com/d/p?z=0&a=1"},
+	}
+
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", tt.target, nil)
+		if tt.rest != "" {
+			ctx.Params = gin.Params{{Key: "rest", Value: tt.rest}}
+		}
+
+		urlRecord := db.Url{ForwardPath: tt.forwardPath, ForwardQuery: tt.forwardQuery}
+		if got := withPassthrough(ctx, urlRecord, tt.destination); got != tt.want {
+			t.Errorf("%s: withPassthrough(%q) = %q, want %q", tt.name, tt.destination, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// previewSuffix appended to a short code shows where the link leads instead
+// of following it, e.g. /abc123+.
+const previewSuffix = "+"
+
+type PreviewResponse struct {
+	ShortCode   string     `json:"short_code"`
+	ShortUrl    string     `json:"short_url"`
+	OriginalUrl string     `json:"original_url,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ClickCount  int64      `json:"click_count"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	HasPassword bool       `json:"has_password"`
+}
+
+type previewPage struct {
+	PreviewResponse
+	QRCode template.URL
+}
+
+func isPreviewRequest(shortCode string) bool {
+	return strings.HasSuffix(shortCode, previewSuffix)
+}
+
+// PreviewUrl shows the destination of a link without redirecting and without
+// counting a click. Browsers get an HTML page with a QR code, API clients
+// asking for JSON get the same details as JSON. The destination of a password
+// protected link stays hidden.
+func (s *Server) PreviewUrl(ctx *gin.Context) {
+	shortCode := strings.TrimSuffix(ctx.Param("short_code"), previewSuffix)
+	if !utils.ValidateShortCode(shortCode) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid short code format"})
+		return
+	}
+
+	urlRecord, err := s.getURLByShortCode(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
+		return
+	}
+
+	if urlRecord.AbuseStatus.Valid {
+		ctx.JSON(http.StatusGone, gin.H{"error": "Short URL has been suspended for abuse"})
+		return
+	}
+
+	stats, err := s.store.GetURLStats(ctx, urlRecord.ShortCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
+		return
+	}
+
+	preview := PreviewResponse{
+		ShortCode:   stats.ShortCode,
+		ShortUrl:    s.config.BaseURL + "/" + stats.ShortCode,
+		CreatedAt:   timestampPtr(stats.CreatedAt),
+		ClickCount:  stats.ClickCount.Int64,
+		ExpiresAt:   timestampPtr(stats.ExpiresAt),
+		ActiveFrom:  timestampPtr(stats.ActiveFrom),
+		HasPassword: stats.HasPassword,
+	}
+	if !stats.HasPassword {
+		preview.OriginalUrl = stats.OriginalUrl
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Vary", "Accept")
+
+	if ctx.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		ctx.JSON(http.StatusOK, preview)
+		return
+	}
+
+	page := previewPage{PreviewResponse: preview}
+	png, err := utils.QRCodePNG(s.qrShortUrl(preview.ShortCode), utils.DefaultQROptions())
+	if err != nil {
+		fmt.Println("Error generating QR code:", err)
+	} else {
+		page.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+	}
+
+	ctx.HTML(http.StatusOK, "preview.html", page)
+}
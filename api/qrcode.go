@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// clickSourceParam marks where a visitor came from, e.g. /abc123?src=qr.
+	// Only known sources are recorded with the click.
+	clickSourceParam = "src"
+	clickSourceQR    = "qr"
+)
+
+var clickSources = map[string]bool{
+	clickSourceQR: true,
+}
+
+type QRCodeRequest struct {
+	Format     string `form:"format" binding:"omitempty,oneof=png svg"`
+	Size       int    `form:"size" binding:"omitempty,min=64,max=2048"`
+	Margin     *int   `form:"margin" binding:"omitempty,min=0,max=16"`
+	Level      string `form:"level" binding:"omitempty,oneof=L M Q H l m q h"`
+	Foreground string `form:"fg"`
+	Background string `form:"bg"`
+	Logo       bool   `form:"logo"`
+}
+
+// clickSource returns the recognised source marker of the request, or "".
+func clickSource(ctx *gin.Context) string {
+	source := ctx.Query(clickSourceParam)
+	if clickSources[source] {
+		return source
+	}
+	return ""
+}
+
+// withClickSource carries the source marker over to a follow-up URL, so a
+// QR scan that goes through the unlock form or the warning page is still
+// recorded as one.
+func withClickSource(path, source string) string {
+	if source == "" {
+		return path
+	}
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + clickSourceParam + "=" + url.QueryEscape(source)
+}
+
+func (s *Server) qrShortUrl(shortCode string) string {
+	return withClickSource(s.config.BaseURL+"/"+shortCode, clickSourceQR)
+}
+
+// GetUrlQRCode renders the short URL as a PNG or SVG QR code for print. The
+// encoded URL carries the qr source marker, so scans show up separately in
+// the click stats.
+func (s *Server) GetUrlQRCode(ctx *gin.Context) {
+	var req QRCodeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	opts, message := s.qrOptions(req)
+	if message != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	shortCode := ctx.Param("url_id")
+	if !utils.ValidateShortCode(shortCode) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid short code format"})
+		return
+	}
+
+	urlRecord, err := s.getURLByShortCode(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
+		return
+	}
+
+	if urlRecord.AbuseStatus.Valid {
+		ctx.JSON(http.StatusGone, gin.H{"error": "Short URL has been suspended for abuse"})
+		return
+	}
+
+	content := s.qrShortUrl(urlRecord.ShortCode)
+
+	var image []byte
+	contentType := "image/png"
+	if req.Format == "svg" {
+		image, err = utils.QRCodeSVG(content, opts)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = utils.QRCodePNG(content, opts)
+	}
+	if err != nil {
+		fmt.Println("Error generating QR code:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Data(http.StatusOK, contentType, image)
+}
+
+// qrOptions applies the request on top of the defaults. It returns an error
+// message for the client when an option is invalid.
+func (s *Server) qrOptions(req QRCodeRequest) (utils.QROptions, string) {
+	opts := utils.DefaultQROptions()
+
+	if req.Size != 0 {
+		opts.Size = req.Size
+	}
+	if req.Margin != nil {
+		opts.Margin = *req.Margin
+	}
+	if req.Level != "" {
+		opts.Level = strings.ToUpper(req.Level)
+	}
+
+	if req.Foreground != "" {
+		fg, err := utils.ParseHexColor(req.Foreground)
+		if err != nil {
+			return opts, "Invalid fg color, expected RRGGBB or RRGGBBAA"
+		}
+		opts.Foreground = fg
+	}
+	if req.Background != "" {
+		bg, err := utils.ParseHexColor(req.Background)
+		if err != nil {
+			return opts, "Invalid bg color, expected RRGGBB or RRGGBBAA"
+		}
+		opts.Background = bg
+	}
+
+	if req.Logo {
+		if s.qrLogo == nil {
+			return opts, "No QR code logo is configured"
+		}
+		opts.Logo = s.qrLogo
+	}
+
+	return opts, ""
+}
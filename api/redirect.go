@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Redirect types a link can use. The HTML ones answer 200 with a page that
+// navigates on its own under a no-referrer policy, so the destination does
+// not learn which short link the visitor came from.
+const (
+	redirectMovedPermanently  = "301"
+	redirectFound             = "302"
+	redirectTemporary         = "307"
+	redirectPermanent         = "308"
+	redirectMetaRefresh       = "meta"
+	redirectJavaScript        = "js"
+	defaultRedirectStatusCode = http.StatusFound
+)
+
+var redirectStatusCodes = map[string]int{
+	redirectMovedPermanently: http.StatusMovedPermanently,
+	redirectFound:            http.StatusFound,
+	redirectTemporary:        http.StatusTemporaryRedirect,
+	redirectPermanent:        http.StatusPermanentRedirect,
+}
+
+// permanentRedirectWarning is returned with links created with 301 or 308.
+const permanentRedirectWarning = "Permanent redirects are cached by browsers: repeat visits go straight to the destination, so they are not counted in analytics and keep working even if the link is later disabled, expires or is moderated."
+
+type redirectPage struct {
+	Destination string
+	JavaScript  bool
+}
+
+func isPermanentRedirect(redirectType string) bool {
+	return redirectType == redirectMovedPermanently || redirectType == redirectPermanent
+}
+
+// resolveRedirectType validates the requested redirect type and returns it
+// with the warning to show, if any. Links that must be checked on every
+// visit cannot use a permanent redirect, since browsers would skip the check.
+func resolveRedirectType(req CreateUrlRequest) (pgtype.Text, string, error) {
+	switch req.RedirectType {
+	case "":
+		return pgtype.Text{}, "", nil
+	case redirectFound, redirectTemporary, redirectMetaRefresh, redirectJavaScript:
+		return pgtype.Text{String: req.RedirectType, Valid: true}, "", nil
+	case redirectMovedPermanently, redirectPermanent:
+	default:
+		return pgtype.Text{}, "", errors.New("redirect_type must be one of 301, 302, 307, 308, meta, js")
+	}
+
+	// Browsers cache the destination they were first sent to, so anything
+	// that picks it per visit would stick to the first visitor's.
+	if req.ExpiresAt != nil || req.TTL != "" || req.ActiveFrom != nil || req.MaxClicks != nil ||
+		req.Password != "" || req.Interstitial || len(req.Variants) > 0 || len(req.ScheduleRules) > 0 ||
+		len(req.GeoRules) > 0 || hasDeviceTargets(req) || req.ForwardQuery != "" || req.ForwardPath {
+		return pgtype.Text{}, "", errors.New("permanent redirects cannot be combined with expiration, scheduling, click limits, passwords, interstitials, split tests, schedule rules, geo rules, device targets or passthrough")
+	}
+	return pgtype.Text{String: req.RedirectType, Valid: true}, permanentRedirectWarning, nil
+}
+
+// redirect sends the visitor on to destination using the link's redirect
+// type.
+func (s *Server) redirect(ctx *gin.Context, urlRecord db.Url, destination string) {
+	redirectType := urlRecord.RedirectType.String
+
+	if redirectType == redirectMetaRefresh || redirectType == redirectJavaScript {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Header("Referrer-Policy", "no-referrer")
+		ctx.HTML(http.StatusOK, "redirect.html", redirectPage{
+			Destination: destination,
+			JavaScript:  redirectType == redirectJavaScript,
+		})
+		return
+	}
+
+	status, ok := redirectStatusCodes[redirectType]
+	if !ok {
+		status = defaultRedirectStatusCode
+	}
+	ctx.Redirect(status, destination)
+}
@@ -0,0 +1,497 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxScheduleRules = 20
+	minutesPerDay    = 24 * 60
+	allWeekdays      = 1<<7 - 1
+
+	scheduleDateLayout  = "2006-01-02"
+	scheduleTimeLayout  = "15:04"
+	scheduleLocalLayout = "2006-01-02T15:04"
+)
+
+var weekdayNames = map[string]int16{
+	"sun": 1 << time.Sunday,
+	"mon": 1 << time.Monday,
+	"tue": 1 << time.Tuesday,
+	"wed": 1 << time.Wednesday,
+	"thu": 1 << time.Thursday,
+	"fri": 1 << time.Friday,
+	"sat": 1 << time.Saturday,
+
+	"weekdays": 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday,
+	"weekend":  1<<time.Saturday | 1<<time.Sunday,
+}
+
+// ScheduleRule sends visits made on the given days, between start_time and
+// end_time and from start_date to end_date, in the link's timezone, to url.
+// Omitted fields do not restrict the rule, but at least one must be set.
+// Times are "15:04" with end_time exclusive ("24:00" is end of day); dates
+// are "2006-01-02" and inclusive.
+type ScheduleRule struct {
+	Days      []string `json:"days,omitempty"`
+	StartTime string   `json:"start_time,omitempty"`
+	EndTime   string   `json:"end_time,omitempty"`
+	StartDate string   `json:"start_date,omitempty"`
+	EndDate   string   `json:"end_date,omitempty"`
+	Url       string   `json:"url"`
+}
+
+type SetScheduleRulesRequest struct {
+	Timezone string         `json:"timezone"`
+	Rules    []ScheduleRule `json:"rules"`
+}
+
+type ScheduleResolveRequest struct {
+	At string `form:"at"`
+}
+
+// loadTimezone resolves an IANA timezone name, UTC when empty.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+func parseMinuteOfDay(value string) (int16, bool) {
+	if value == "24:00" {
+		return minutesPerDay, true
+	}
+	t, err := time.Parse(scheduleTimeLayout, value)
+	if err != nil {
+		return 0, false
+	}
+	return int16(t.Hour()*60 + t.Minute()), true
+}
+
+func formatMinuteOfDay(minute int16) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// parseScheduleRule turns a rule into its stored form. It returns a message
+// for the client when the rule is invalid.
+func parseScheduleRule(rule ScheduleRule) (db.UrlScheduleRule, string) {
+	parsed := db.UrlScheduleRule{Days: allWeekdays, Destination: rule.Url}
+
+	if len(rule.Days) > 0 {
+		parsed.Days = 0
+		for _, name := range rule.Days {
+			day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return parsed, fmt.Sprintf("invalid day %q, use mon-sun, weekdays or weekend", name)
+			}
+			parsed.Days |= day
+		}
+	}
+
+	if rule.StartTime != "" || rule.EndTime != "" {
+		start, ok := parseMinuteOfDay(rule.StartTime)
+		if !ok || start == minutesPerDay {
+			return parsed, "start_time must be HH:MM"
+		}
+		end, ok := parseMinuteOfDay(rule.EndTime)
+		if !ok || end == 0 {
+			return parsed, "end_time must be HH:MM"
+		}
+		// A window past midnight, like 22:00-06:00, is two rules.
+		if start >= end {
+			return parsed, "start_time must be before end_time, split windows that cross midnight into two rules"
+		}
+		parsed.StartMinute = pgtype.Int2{Int16: start, Valid: true}
+		parsed.EndMinute = pgtype.Int2{Int16: end, Valid: true}
+	}
+
+	if rule.StartDate != "" {
+		start, err := time.Parse(scheduleDateLayout, rule.StartDate)
+		if err != nil {
+			return parsed, "start_date must be YYYY-MM-DD"
+		}
+		parsed.StartDate = pgtype.Date{Time: start, Valid: true}
+	}
+	if rule.EndDate != "" {
+		end, err := time.Parse(scheduleDateLayout, rule.EndDate)
+		if err != nil {
+			return parsed, "end_date must be YYYY-MM-DD"
+		}
+		parsed.EndDate = pgtype.Date{Time: end, Valid: true}
+	}
+	if parsed.StartDate.Valid && parsed.EndDate.Valid && parsed.EndDate.Time.Before(parsed.StartDate.Time) {
+		return parsed, "start_date must not be after end_date"
+	}
+
+	if len(rule.Days) == 0 && !parsed.StartMinute.Valid && !parsed.StartDate.Valid && !parsed.EndDate.Valid {
+		return parsed, "set at least one of days, start_time/end_time or start_date/end_date"
+	}
+	return parsed, ""
+}
+
+func scheduleRuleResponse(rule db.UrlScheduleRule) ScheduleRule {
+	response := ScheduleRule{Url: rule.Destination}
+	if rule.Days != allWeekdays {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if rule.Days&(1<<day) != 0 {
+				response.Days = append(response.Days, strings.ToLower(day.String()[:3]))
+			}
+		}
+	}
+	if rule.StartMinute.Valid {
+		response.StartTime = formatMinuteOfDay(rule.StartMinute.Int16)
+		response.EndTime = formatMinuteOfDay(rule.EndMinute.Int16)
+	}
+	if rule.StartDate.Valid {
+		response.StartDate = rule.StartDate.Time.Format(scheduleDateLayout)
+	}
+	if rule.EndDate.Valid {
+		response.EndDate = rule.EndDate.Time.Format(scheduleDateLayout)
+	}
+	return response
+}
+
+func scheduleRuleResponses(rules []db.UrlScheduleRule) []ScheduleRule {
+	response := make([]ScheduleRule, len(rules))
+	for i, rule := range rules {
+		response[i] = scheduleRuleResponse(rule)
+	}
+	return response
+}
+
+// scheduleSpan is the set of local times a rule matches, with open date
+// bounds widened to the zero and far-future dates.
+type scheduleSpan struct {
+	days       int16
+	start, end int16
+	from, to   time.Time
+}
+
+var scheduleFarFuture = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func spanOf(rule db.UrlScheduleRule) scheduleSpan {
+	span := scheduleSpan{days: rule.Days, end: minutesPerDay, to: scheduleFarFuture}
+	if rule.StartMinute.Valid {
+		span.start, span.end = rule.StartMinute.Int16, rule.EndMinute.Int16
+	}
+	if rule.StartDate.Valid {
+		span.from = rule.StartDate.Time
+	}
+	if rule.EndDate.Valid {
+		span.to = rule.EndDate.Time
+	}
+	return span
+}
+
+// activeDays narrows days to the weekdays that occur between from and to.
+func activeDays(days int16, from, to time.Time) int16 {
+	if to.Sub(from) >= 6*24*time.Hour {
+		return days
+	}
+	var occurring int16
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		occurring |= 1 << d.Weekday()
+	}
+	return days & occurring
+}
+
+func (a scheduleSpan) overlaps(b scheduleSpan) bool {
+	from, to := a.from, a.to
+	if b.from.After(from) {
+		from = b.from
+	}
+	if b.to.Before(to) {
+		to = b.to
+	}
+	if to.Before(from) {
+		return false
+	}
+	return activeDays(a.days&b.days, from, to) != 0 && max(a.start, b.start) < min(a.end, b.end)
+}
+
+// covers reports whether every time b matches is also matched by a.
+func (a scheduleSpan) covers(b scheduleSpan) bool {
+	return activeDays(b.days, b.from, b.to)&^a.days == 0 &&
+		!b.from.Before(a.from) && !b.to.After(a.to) &&
+		a.start <= b.start && b.end <= a.end
+}
+
+// checkScheduleRules looks at the rules as a whole. Rules are tried in
+// order and the first match wins, so a rule that an earlier one fully
+// covers can never match and is refused; partial overlaps are allowed and
+// returned as warnings.
+func checkScheduleRules(rules []db.UrlScheduleRule) ([]string, string) {
+	var warnings []string
+	spans := make([]scheduleSpan, len(rules))
+	for i, rule := range rules {
+		spans[i] = spanOf(rule)
+		if activeDays(spans[i].days, spans[i].from, spans[i].to) == 0 {
+			return nil, fmt.Sprintf("Schedule rule %d never matches, none of its days fall between its dates", i+1)
+		}
+		for j := range i {
+			if spans[j].covers(spans[i]) {
+				return nil, fmt.Sprintf("Schedule rule %d never matches, rule %d covers all of it", i+1, j+1)
+			}
+			if spans[j].overlaps(spans[i]) {
+				warnings = append(warnings, fmt.Sprintf("Schedule rule %d overlaps rule %d, rule %d wins where they overlap", i+1, j+1, j+1))
+			}
+		}
+	}
+	return warnings, ""
+}
+
+// resolveScheduleRules validates a timezone and its rules, in order. The
+// destinations pass the same policy as long_url. It writes a 400 response
+// and returns false when refused; otherwise it returns the timezone name to
+// store and any overlap warnings.
+func (s *Server) resolveScheduleRules(ctx *gin.Context, timezone string, rules []ScheduleRule) ([]db.UrlScheduleRule, string, []string, bool) {
+	if len(rules) == 0 {
+		return nil, "", nil, true
+	}
+	if len(rules) > maxScheduleRules {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d schedule rules per link", maxScheduleRules)})
+		return nil, "", nil, false
+	}
+
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown timezone %q, expected an IANA name such as \"Europe/Berlin\"", timezone)})
+		return nil, "", nil, false
+	}
+
+	parsed := make([]db.UrlScheduleRule, len(rules))
+	for i, rule := range rules {
+		var msg string
+		parsed[i], msg = parseScheduleRule(rule)
+		if msg != "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Schedule rule %d: %s", i+1, msg)})
+			return nil, "", nil, false
+		}
+		if !isValidURL(rule.Url) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid URL for schedule rule %d", i+1)})
+			return nil, "", nil, false
+		}
+		if !s.checkDestination(ctx, rule.Url) {
+			return nil, "", nil, false
+		}
+	}
+
+	warnings, msg := checkScheduleRules(parsed)
+	if msg != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return nil, "", nil, false
+	}
+	return parsed, loc.String(), warnings, true
+}
+
+// resolveSchedule validates the schedule rules of a create request. A visit
+// has to be routed by one kind of rule only, so they cannot be mixed with
+// device targets, geo rules or split tests.
+func (s *Server) resolveSchedule(ctx *gin.Context, req CreateUrlRequest) ([]db.UrlScheduleRule, string, []string, bool) {
+	if len(req.ScheduleRules) == 0 {
+		if req.Timezone != "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "timezone requires schedule_rules"})
+			return nil, "", nil, false
+		}
+		return nil, "", nil, true
+	}
+	if hasDeviceTargets(req) || len(req.GeoRules) > 0 || len(req.Variants) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Schedule rules cannot be combined with device targets, geo rules or split tests"})
+		return nil, "", nil, false
+	}
+	return s.resolveScheduleRules(ctx, req.Timezone, req.ScheduleRules)
+}
+
+func scheduleRuleMatches(rule db.UrlScheduleRule, local time.Time) bool {
+	if rule.Days&(1<<local.Weekday()) == 0 {
+		return false
+	}
+
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	if rule.StartDate.Valid && date.Before(rule.StartDate.Time) {
+		return false
+	}
+	if rule.EndDate.Valid && date.After(rule.EndDate.Time) {
+		return false
+	}
+
+	if rule.StartMinute.Valid {
+		minute := int16(local.Hour()*60 + local.Minute())
+		if minute < rule.StartMinute.Int16 || minute >= rule.EndMinute.Int16 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchScheduleRule returns the index of the first rule matching t in loc,
+// or -1.
+func matchScheduleRule(rules []db.UrlScheduleRule, t time.Time, loc *time.Location) int {
+	local := t.In(loc)
+	for i, rule := range rules {
+		if scheduleRuleMatches(rule, local) {
+			return i
+		}
+	}
+	return -1
+}
+
+func linkLocation(urlRecord db.Url) *time.Location {
+	loc, err := loadTimezone(urlRecord.Timezone.String)
+	if err != nil {
+		log.Printf("link %s has unknown timezone %q, using UTC", urlRecord.ShortCode, urlRecord.Timezone.String)
+		return time.UTC
+	}
+	return loc
+}
+
+// scheduleDestination returns the destination of the first rule matching
+// t, or original_url when none does or the rules cannot be loaded.
+func (s *Server) scheduleDestination(ctx *gin.Context, urlRecord db.Url, t time.Time) string {
+	rules, err := s.store.ListScheduleRulesByURLID(ctx, urlRecord.ID)
+	if err != nil {
+		log.Println("failed to load schedule rules:", err)
+		return urlRecord.OriginalUrl
+	}
+
+	if i := matchScheduleRule(rules, t, linkLocation(urlRecord)); i >= 0 {
+		return rules[i].Destination
+	}
+	return urlRecord.OriginalUrl
+}
+
+// ResolveUrlSchedule tells where a link would send a visitor at a given
+// time, "at" as RFC 3339 or as "2006-01-02T15:04" in the link's timezone,
+// now by default. Other routing (variants, geo rules, device targets) is
+// not applied.
+func (s *Server) ResolveUrlSchedule(ctx *gin.Context) {
+	var req ScheduleResolveRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	// Only links a visitor could follow right now are resolved.
+	urlRecord, ok := s.findRedirectUrl(ctx, ctx.Param("url_id"))
+	if !ok {
+		return
+	}
+
+	// Answering would reveal the destination without the password.
+	if urlRecord.PasswordHash.Valid {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Short URL is password-protected"})
+		return
+	}
+
+	loc := linkLocation(urlRecord)
+	at := time.Now()
+	if req.At != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, req.At)
+		if err != nil {
+			at, err = time.ParseInLocation(scheduleLocalLayout, req.At, loc)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "at must be RFC 3339 or YYYY-MM-DDTHH:MM in the link's timezone"})
+			return
+		}
+	}
+
+	rules, err := s.store.ListScheduleRulesByURLID(ctx, urlRecord.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule rules"})
+		return
+	}
+
+	var rule *int
+	destination := urlRecord.OriginalUrl
+	if i := matchScheduleRule(rules, at, loc); i >= 0 {
+		number := i + 1
+		rule = &number
+		destination = rules[i].Destination
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code":  urlRecord.ShortCode,
+		"at":          at.UTC(),
+		"timezone":    loc.String(),
+		"local_time":  at.In(loc).Format(time.RFC3339),
+		"rule":        rule,
+		"destination": destination,
+	})
+}
+
+// GetUrlScheduleRules lists the schedule rules of a link in order.
+func (s *Server) GetUrlScheduleRules(ctx *gin.Context) {
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	rules, err := s.store.ListScheduleRulesByURLID(ctx, urlRecord.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule rules"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code": urlRecord.ShortCode,
+		"default":    urlRecord.OriginalUrl,
+		"timezone":   linkLocation(urlRecord).String(),
+		"rules":      scheduleRuleResponses(rules),
+	})
+}
+
+// SetUrlScheduleRules replaces the schedule rules of a link. An empty rules
+// list removes them all.
+func (s *Server) SetUrlScheduleRules(ctx *gin.Context) {
+	var req SetScheduleRulesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	urlRecord, ok := s.findModeratedUrl(ctx)
+	if !ok {
+		return
+	}
+
+	if len(req.Rules) > 0 {
+		if isDeviceTargeted(urlRecord) || urlRecord.GeoTargeted || urlRecord.VariantMode.Valid {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Schedule rules cannot be combined with device targets, geo rules or split tests"})
+			return
+		}
+		if isPermanentRedirect(urlRecord.RedirectType.String) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Permanent redirects cannot be combined with schedule rules"})
+			return
+		}
+	}
+
+	rules, timezone, warnings, ok := s.resolveScheduleRules(ctx, req.Timezone, req.Rules)
+	if !ok {
+		return
+	}
+
+	err := s.store.SetScheduleRulesTx(ctx, db.SetScheduleRulesTxParams{UrlID: urlRecord.ID, Timezone: timezone, Rules: rules})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store schedule rules"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"short_code": urlRecord.ShortCode,
+		"default":    urlRecord.OriginalUrl,
+		"timezone":   timezone,
+		"rules":      scheduleRuleResponses(rules),
+		"warnings":   warnings,
+	})
+}
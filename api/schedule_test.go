@@ -0,0 +1,104 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	db "url-shortener/db/sqlc"
+)
+
+func TestCheckScheduleRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []ScheduleRule
+		warnings []string
+		err      string
+	}{
+		{
+			name: "disjoint days",
+			rules: []ScheduleRule{
+				{Days: []string{"weekdays"}},
+				{Days: []string{"weekend"}},
+			},
+		},
+		{
+			name: "back to back hours",
+			rules: []ScheduleRule{
+				{StartTime: "09:00", EndTime: "12:00"},
+				{StartTime: "12:00", EndTime: "17:00"},
+			},
+		},
+		{
+			name: "partial overlap warns",
+			rules: []ScheduleRule{
+				{StartTime: "09:00", EndTime: "13:00"},
+				{StartTime: "12:00", EndTime: "17:00"},
+			},
+			warnings: []string{"Schedule rule 2 overlaps rule 1, rule 1 wins where they overlap"},
+		},
+		{
+			name: "same hours on other days",
+			rules: []ScheduleRule{
+				{Days: []string{"mon"}, StartTime: "09:00", EndTime: "17:00"},
+				{Days: []string{"tue"}, StartTime: "09:00", EndTime: "17:00"},
+			},
+		},
+		{
+			name: "covered rule is refused",
+			rules: []ScheduleRule{
+				{Days: []string{"weekdays"}},
+				{Days: []string{"mon"}, StartTime: "09:00", EndTime: "17:00"},
+			},
+			err: "Schedule rule 2 never matches, rule 1 covers all of it",
+		},
+		{
+			name: "covered date range is refused",
+			rules: []ScheduleRule{
+				{StartDate: "2026-01-01", EndDate: "2026-12-31"},
+				{StartDate: "2026-03-01", EndDate: "2026-03-31"},
+			},
+			err: "Schedule rule 2 never matches, rule 1 covers all of it",
+		},
+		{
+			name: "wider later rule only warns",
+			rules: []ScheduleRule{
+				{Days: []string{"mon"}, StartTime: "09:00", EndTime: "17:00"},
+				{Days: []string{"weekdays"}},
+			},
+			warnings: []string{"Schedule rule 2 overlaps rule 1, rule 1 wins where they overlap"},
+		},
+		{
+			name: "days outside the dates never match",
+			rules: []ScheduleRule{
+				// 2026-10-19 is a Monday.
+				{Days: []string{"weekend"}, StartDate: "2026-10-19", EndDate: "2026-10-20"},
+			},
+			err: "Schedule rule 1 never matches, none of its days fall between its dates",
+		},
+		{
+			name: "short range that shares no days does not overlap",
+			rules: []ScheduleRule{
+				{Days: []string{"mon"}, StartDate: "2026-10-19", EndDate: "2026-10-19"},
+				{Days: []string{"tue"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		rules := make([]db.UrlScheduleRule, len(tt.rules))
+		for i, rule := range tt.rules {
+			rule.Url = "https://example.com"
+			var msg string
+			if rules[i], msg = parseScheduleRule(rule); msg != "" {
+				t.Fatalf("%s: rule %d: %s", tt.name, i+1, msg)
+			}
+		}
+
+		warnings, msg := checkScheduleRules(rules)
+		if msg != tt.err {
+			t.Errorf("%s: got error %q, want %q", tt.name, msg, tt.err)
+		}
+		if !reflect.DeepEqual(warnings, tt.warnings) {
+			t.Errorf("%s: got warnings %q, want %q", tt.name, warnings, tt.warnings)
+		}
+	}
+}
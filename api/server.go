@@ -1,9 +1,15 @@
 package api
 
 import (
+	"crypto/rand"
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
 	"time"
 	middleware "url-shortener/middlewares"
 	"url-shortener/utils"
+	"url-shortener/worker"
 
 	db "url-shortener/db/sqlc"
 
@@ -11,22 +17,112 @@ import (
 )
 
 type Server struct {
-	router *gin.Engine
-	config *utils.Config
-	store  db.Store
+	router    *gin.Engine
+	templates *template.Template
+	config    *utils.Config
+	store     db.Store
+	sweeper   *worker.Sweeper
+	codes     codeGenerator
+	// wordCodes serves requests with code_style "words".
+	wordCodes codeGenerator
+
+	codeFilter    *utils.CodeFilter
+	destination   *utils.DestinationPolicy
+	bannedDomains *utils.BannedDomainCheck
+	qrLogo        *utils.QRLogo
+	// appLinkSchemes are the URL schemes allowed for app deep links.
+	appLinkSchemes []string
+
+	cookieSecret      []byte
+	unlockIPLimiter   *middleware.RateLimiter
+	unlockLinkLimiter *middleware.RateLimiter
+	reportLimiter     *middleware.RateLimiter
 }
 
-func NewServer(config *utils.Config, store db.Store) *Server {
+func NewServer(config *utils.Config, store db.Store, sweeper *worker.Sweeper, threatFeed *utils.ThreatFeed, bannedDomains *utils.BannedDomainCheck) (*Server, error) {
+	codeFilter, err := utils.NewCodeFilter(config.CodeFilterFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load code filter: %w", err)
+	}
+
+	if config.CaseInsensitiveCodes {
+		if err := ensureCaseFoldIndex(store); err != nil {
+			return nil, err
+		}
+	}
+
+	codes, err := newCodeGenerator(config, store, codeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create code generator: %w", err)
+	}
+
+	wordCodes, err := newWordCodeGenerator(config, store, codeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create word code generator: %w", err)
+	}
+
+	templates, err := loadTemplates(config.TemplateDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load templates: %w", err)
+	}
+
+	var qrLogo *utils.QRLogo
+	if config.QRLogoFile != "" {
+		qrLogo, err = utils.LoadQRLogo(config.QRLogoFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	server := &Server{
-		config: config,
-		store:  store,
+		config:            config,
+		templates:         templates,
+		store:             store,
+		sweeper:           sweeper,
+		codes:             codes,
+		wordCodes:         wordCodes,
+		codeFilter:        codeFilter,
+		destination:       newDestinationPolicy(config, threatFeed, bannedDomains),
+		bannedDomains:     bannedDomains,
+		qrLogo:            qrLogo,
+		appLinkSchemes:    parseAppLinkSchemes(config.AppLinkSchemes),
+		cookieSecret:      []byte(config.CookieSecret),
+		unlockIPLimiter:   middleware.NewRateLimiter(5, 15*time.Minute),
+		unlockLinkLimiter: middleware.NewRateLimiter(20, 15*time.Minute),
+		reportLimiter:     middleware.NewRateLimiter(10, time.Hour),
 	}
+
+	if len(server.cookieSecret) == 0 {
+		log.Println("COOKIE_SECRET is not set. Using a random secret, unlock cookies will not survive restarts.")
+		server.cookieSecret = make([]byte, 32)
+		if _, err := rand.Read(server.cookieSecret); err != nil {
+			log.Fatal("cannot generate cookie secret:", err)
+		}
+	}
+
 	server.setupRouter()
-	return server
+	// Rate limits and abuse reports key on ctx.ClientIP(), which must not
+	// take X-Forwarded-For from anyone but our own proxies.
+	if err := server.router.SetTrustedProxies(parseTrustedProxies(config.TrustedProxies)); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	return server, nil
+}
+
+// parseTrustedProxies reads the comma-separated TRUSTED_PROXIES list.
+func parseTrustedProxies(list string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(list, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
 }
 
 func (s *Server) setupRouter() {
 	s.router = gin.Default()
+	s.router.SetHTMLTemplate(s.templates)
 
 	s.router.Use(middleware.CORS(s.config.FrontendURL))
 	s.router.Use(middleware.RateLimit())
@@ -34,6 +130,10 @@ func (s *Server) setupRouter() {
 	s.router.StaticFile("/favicon.ico", "./Go.svg")
 
 	s.router.GET("/:short_code", s.RedirectToLongUrl)
+	s.router.POST("/:short_code", s.UnlockUrl)
+	// Path passthrough, /docs/api/v2 on a /docs link with forward_path.
+	s.router.GET("/:short_code/*rest", s.RedirectToLongUrl)
+	s.router.POST("/:short_code/*rest", s.UnlockUrl)
 
 	s.router.GET("/health", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{
@@ -52,12 +152,42 @@ func (s *Server) setupRouter() {
 	apiRoutes.GET("/url", s.GetListUrls)
 
 	apiRoutes.POST("/url/shorten", s.CreateUrl)
+	apiRoutes.POST("/url/shorten/bulk", s.CreateUrlsBulk)
+
+	apiRoutes.POST("/url/:short_code/report", s.ReportUrl)
 
 	apiRoutes.GET("/url/:url_id/stats", s.GetUrlStats)
+	// gin allows one wildcard name per segment, the short code arrives as :url_id.
+	apiRoutes.GET("/url/:url_id/qr", s.GetUrlQRCode)
+	apiRoutes.GET("/url/:url_id/resolve", s.ResolveUrlSchedule)
 	apiRoutes.GET("/url/:url_id/stats/count", s.GetUrlClickCount)
+	apiRoutes.GET("/url/:url_id/stats/variants", s.GetUrlVariantStats)
 
 	apiRoutes.GET("/metrics", s.GetMetrics)
 
+	adminRoutes := apiRoutes.Group("/admin", middleware.AdminAuth(s.config.AdminToken))
+
+	adminRoutes.GET("/code-filter", s.GetCodeFilter)
+	adminRoutes.PUT("/code-filter", s.ReplaceCodeFilter)
+	adminRoutes.POST("/code-filter/:list", s.AddCodeFilterWord)
+	adminRoutes.DELETE("/code-filter/:list/:word", s.RemoveCodeFilterWord)
+	adminRoutes.GET("/short-code-conflicts", s.GetShortCodeConflicts)
+
+	adminRoutes.GET("/reports", s.ListReportedUrls)
+	adminRoutes.GET("/reports/:short_code", s.GetUrlReports)
+	adminRoutes.POST("/reports/:short_code/dismiss", s.DismissUrlReports)
+	adminRoutes.POST("/reports/:short_code/ban", s.BanUrl)
+
+	adminRoutes.PUT("/urls/:short_code/interstitial", s.SetUrlInterstitial)
+	adminRoutes.GET("/urls/:short_code/geo-rules", s.GetUrlGeoRules)
+	adminRoutes.PUT("/urls/:short_code/geo-rules", s.SetUrlGeoRules)
+	adminRoutes.GET("/urls/:short_code/schedule-rules", s.GetUrlScheduleRules)
+	adminRoutes.PUT("/urls/:short_code/schedule-rules", s.SetUrlScheduleRules)
+
+	adminRoutes.GET("/banned-domains", s.ListBannedDomains)
+	adminRoutes.POST("/banned-domains", s.BanDomain)
+	adminRoutes.DELETE("/banned-domains/:domain", s.UnbanDomain)
+
 }
 
 func (s *Server) Start(address string) error {
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// blockedAppLinkSchemes can run code or read local files in the browser and
+// are never accepted as deep links, whatever APP_LINK_SCHEMES says.
+var blockedAppLinkSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+	"file":       true,
+	"blob":       true,
+	"about":      true,
+}
+
+type deviceTargets struct {
+	ios     pgtype.Text
+	android pgtype.Text
+	desktop pgtype.Text
+	app     pgtype.Text
+}
+
+type appLinkPage struct {
+	AppUrl   template.URL
+	Fallback string
+}
+
+// parseAppLinkSchemes reads the comma-separated APP_LINK_SCHEMES list.
+func parseAppLinkSchemes(list string) []string {
+	var schemes []string
+	for _, scheme := range strings.Split(list, ",") {
+		scheme = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(scheme), "://"))
+		switch {
+		case scheme == "", scheme == "http", scheme == "https":
+		case blockedAppLinkSchemes[scheme]:
+			log.Printf("APP_LINK_SCHEMES: ignoring unsafe scheme %q", scheme)
+		default:
+			schemes = append(schemes, scheme)
+		}
+	}
+	return schemes
+}
+
+func hasDeviceTargets(req CreateUrlRequest) bool {
+	return req.IosUrl != "" || req.AndroidUrl != "" || req.DesktopUrl != "" || req.AppUrl != ""
+}
+
+// resolveDeviceTargets validates the per-platform destinations of a request.
+// They pass the same destination policy as long_url. It writes a 400
+// response and returns false when one is refused.
+func (s *Server) resolveDeviceTargets(ctx *gin.Context, req CreateUrlRequest) (deviceTargets, bool) {
+	var targets deviceTargets
+
+	platformUrls := []struct {
+		field string
+		raw   string
+		dst   *pgtype.Text
+	}{
+		{"ios_url", req.IosUrl, &targets.ios},
+		{"android_url", req.AndroidUrl, &targets.android},
+		{"desktop_url", req.DesktopUrl, &targets.desktop},
+	}
+	for _, target := range platformUrls {
+		if target.raw == "" {
+			continue
+		}
+		if !isValidURL(target.raw) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid %s format", target.field)})
+			return deviceTargets{}, false
+		}
+		if !s.checkDestination(ctx, target.raw) {
+			return deviceTargets{}, false
+		}
+		*target.dst = pgtype.Text{String: target.raw, Valid: true}
+	}
+
+	if req.AppUrl != "" {
+		// Web URLs, universal links included, belong in the platform fields.
+		if !isValidURL(req.AppUrl, s.appLinkSchemes...) || isValidURL(req.AppUrl) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "app_url must use an allowed app scheme"})
+			return deviceTargets{}, false
+		}
+		targets.app = pgtype.Text{String: req.AppUrl, Valid: true}
+	}
+
+	return targets, true
+}
+
+// deviceDestination picks where this visitor goes: the URL for their
+// platform if the link has one, otherwise destination. On phones it also
+// returns the app deep link to try first, if any.
+func deviceDestination(ctx *gin.Context, urlRecord db.Url, destination string) (string, string) {
+	var target pgtype.Text
+	platform := utils.DetectPlatform(ctx.Request.UserAgent())
+	switch platform {
+	case utils.PlatformIOS:
+		target = urlRecord.IosUrl
+	case utils.PlatformAndroid:
+		target = urlRecord.AndroidUrl
+	case utils.PlatformDesktop:
+		target = urlRecord.DesktopUrl
+	}
+	if target.Valid {
+		destination = target.String
+	}
+
+	if urlRecord.AppUrl.Valid && (platform == utils.PlatformIOS || platform == utils.PlatformAndroid) {
+		return destination, urlRecord.AppUrl.String
+	}
+	return destination, ""
+}
+
+func isDeviceTargeted(urlRecord db.Url) bool {
+	return urlRecord.IosUrl.Valid || urlRecord.AndroidUrl.Valid || urlRecord.DesktopUrl.Valid || urlRecord.AppUrl.Valid
+}
+
+// renderAppLink serves a page that opens the app and, if nothing happened
+// after a moment because the app is not installed, falls back to
+// destination, usually the app store page.
+func (s *Server) renderAppLink(ctx *gin.Context, appUrl, destination string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.HTML(http.StatusOK, "applink.html", appLinkPage{
+		AppUrl:   template.URL(appUrl),
+		Fallback: destination,
+	})
+}
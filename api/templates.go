@@ -0,0 +1,30 @@
+package api
+
+import (
+	"embed"
+	"html/template"
+	"path/filepath"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// loadTemplates parses the built-in pages, then any *.html files in dir. A
+// file with the same name as a built-in page (e.g. interstitial.html)
+// replaces it.
+func loadTemplates(dir string) (*template.Template, error) {
+	templates, err := template.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return templates, nil
+	}
+
+	overrides, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil || len(overrides) == 0 {
+		return templates, err
+	}
+	return templates.ParseFiles(overrides...)
+}
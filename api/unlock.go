@@ -0,0 +1,139 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	unlockCookiePrefix     = "unlock_"
+	defaultUnlockCookieTTL = time.Hour
+
+	minPasswordLength = 4
+	// bcrypt ignores everything past 72 bytes.
+	maxPasswordLength = 72
+)
+
+type unlockPage struct {
+	ShortCode string
+	// Action is the form target, the short URL as the visitor asked for it.
+	// See followUpPath.
+	Action string
+	Error  string
+}
+
+func validatePassword(password string) string {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return fmt.Sprintf("Password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	}
+	return ""
+}
+
+// UnlockUrl checks the password submitted from the unlock form. On success it
+// sets a short-lived signed cookie and sends the visitor back through the
+// regular redirect, which then records the click.
+func (s *Server) UnlockUrl(ctx *gin.Context) {
+	urlRecord, ok := s.findRedirectUrl(ctx, ctx.Param("short_code"))
+	if !ok {
+		return
+	}
+
+	target := followUpPath(ctx, urlRecord)
+
+	if !urlRecord.PasswordHash.Valid {
+		ctx.Redirect(http.StatusSeeOther, target)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+
+	ipKey := "ip:" + ctx.ClientIP()
+	linkKey := "link:" + urlRecord.ShortCode
+
+	if s.unlockIPLimiter.Blocked(ipKey) || s.unlockLinkLimiter.Blocked(linkKey) {
+		ctx.HTML(http.StatusTooManyRequests, "unlock.html", unlockPage{
+			ShortCode: urlRecord.ShortCode,
+			Action:    target,
+			Error:     "Too many failed attempts. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.CheckPassword(ctx.PostForm("password"), urlRecord.PasswordHash.String); err != nil {
+		s.unlockIPLimiter.Allow(ipKey)
+		s.unlockLinkLimiter.Allow(linkKey)
+
+		ctx.HTML(http.StatusUnauthorized, "unlock.html", unlockPage{
+			ShortCode: urlRecord.ShortCode,
+			Action:    target,
+			Error:     "Incorrect password",
+		})
+		return
+	}
+
+	s.setUnlockCookie(ctx, urlRecord)
+	ctx.Redirect(http.StatusSeeOther, target)
+}
+
+func (s *Server) unlockCookieTTL() time.Duration {
+	if s.config.UnlockCookieTTL > 0 {
+		return s.config.UnlockCookieTTL
+	}
+	return defaultUnlockCookieTTL
+}
+
+// signUnlock binds the cookie to the link and its current password hash, so
+// changing the password invalidates cookies handed out before.
+func (s *Server) signUnlock(urlRecord db.Url, expires int64) string {
+	mac := hmac.New(sha256.New, s.cookieSecret)
+	fmt.Fprintf(mac, "%s|%d|%s", urlRecord.ShortCode, expires, urlRecord.PasswordHash.String)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) setUnlockCookie(ctx *gin.Context, urlRecord db.Url) {
+	ttl := s.unlockCookieTTL()
+	expires := time.Now().Add(ttl).Unix()
+	value := strconv.FormatInt(expires, 10) + "." + s.signUnlock(urlRecord, expires)
+
+	// The cookie name carries the stored code. A path of "/"+code would not
+	// match when the visitor types the code in another case.
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(
+		unlockCookiePrefix+urlRecord.ShortCode,
+		value,
+		int(ttl.Seconds()),
+		"/",
+		"",
+		strings.HasPrefix(s.config.BaseURL, "https://"),
+		true,
+	)
+}
+
+func (s *Server) hasUnlockCookie(ctx *gin.Context, urlRecord db.Url) bool {
+	value, err := ctx.Cookie(unlockCookiePrefix + urlRecord.ShortCode)
+	if err != nil {
+		return false
+	}
+
+	rawExpires, signature, found := strings.Cut(value, ".")
+	if !found {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.signUnlock(urlRecord, expires)))
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 	db "url-shortener/db/sqlc"
 	"url-shortener/utils"
@@ -18,22 +20,218 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const maxRetries = 5
+
 type CreateUrlRequest struct {
-	LongUrl string `json:"long_url" binding:"required"`
+	LongUrl       string     `json:"long_url" binding:"required"`
+	Alias         string     `json:"alias"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	TTL           string     `json:"ttl"`
+	ReuseExisting *bool      `json:"reuse_existing"`
+	Password      string     `json:"password"`
+	MaxClicks     *int64     `json:"max_clicks"`
+	ActiveFrom    *time.Time `json:"active_from"`
+	CodeStyle     string     `json:"code_style" binding:"omitempty,oneof=random words"`
+	Interstitial  bool       `json:"interstitial"`
+	RedirectType  string     `json:"redirect_type" binding:"omitempty,oneof=301 302 307 308 meta js"`
+	IosUrl        string     `json:"ios_url"`
+	AndroidUrl    string     `json:"android_url"`
+	DesktopUrl    string     `json:"desktop_url"`
+	AppUrl        string     `json:"app_url"`
+	// GeoRules maps ISO country codes to destinations; other countries go
+	// to long_url.
+	GeoRules map[string]string `json:"geo_rules"`
+	// Variants split visits between several destinations by weight;
+	// long_url is only used if they cannot be loaded.
+	Variants    []VariantRequest `json:"variants"`
+	VariantMode string           `json:"variant_mode" binding:"omitempty,oneof=random sticky"`
+	// ScheduleRules are tried in order in Timezone (IANA name, UTC by
+	// default); visits no rule matches go to long_url.
+	ScheduleRules []ScheduleRule `json:"schedule_rules"`
+	Timezone      string         `json:"timezone"`
+	// ForwardQuery passes the visitor's query string on, ForwardPath what
+	// follows the short code in the path.
+	ForwardQuery string `json:"forward_query" binding:"omitempty,oneof=merge override"`
+	ForwardPath  bool   `json:"forward_path"`
 }
 
 type CreateUrlResponse struct {
-	ShortUrl string `json:"short_url"`
+	ShortUrl         string            `json:"short_url"`
+	ActiveFrom       *time.Time        `json:"active_from,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	MaxClicks        *int64            `json:"max_clicks,omitempty"`
+	Interstitial     string            `json:"interstitial,omitempty"`
+	RedirectType     string            `json:"redirect_type,omitempty"`
+	IosUrl           string            `json:"ios_url,omitempty"`
+	AndroidUrl       string            `json:"android_url,omitempty"`
+	DesktopUrl       string            `json:"desktop_url,omitempty"`
+	AppUrl           string            `json:"app_url,omitempty"`
+	GeoRules         map[string]string `json:"geo_rules,omitempty"`
+	Variants         []VariantResponse `json:"variants,omitempty"`
+	VariantMode      string            `json:"variant_mode,omitempty"`
+	ScheduleRules    []ScheduleRule    `json:"schedule_rules,omitempty"`
+	Timezone         string            `json:"timezone,omitempty"`
+	Warning          string            `json:"warning,omitempty"`
+	ScheduleWarnings []string          `json:"schedule_warnings,omitempty"`
+	ForwardQuery     string            `json:"forward_query,omitempty"`
+	ForwardPath      bool              `json:"forward_path,omitempty"`
+	Reused           bool              `json:"reused,omitempty"`
+}
+
+// shouldReuse reports whether an existing active link for the same
+// destination may be returned instead of creating a new one. Requests asking
+// for a specific alias, schedule, password or click limit always get their
+// own link, and so do requests for an interstitial, a redirect type, device
+// targets, geo rules, variants, schedule rules, passthrough or word codes
+// since an existing link would hand back a code in the wrong style.
+func (s *Server) shouldReuse(req CreateUrlRequest) bool {
+	if req.Alias != "" || req.ExpiresAt != nil || req.TTL != "" || req.ActiveFrom != nil ||
+		req.Password != "" || req.MaxClicks != nil || req.CodeStyle == codeStyleWords || req.Interstitial ||
+		req.RedirectType != "" || hasDeviceTargets(req) || len(req.GeoRules) > 0 ||
+		len(req.Variants) > 0 || len(req.ScheduleRules) > 0 || req.ForwardQuery != "" || req.ForwardPath {
+		return false
+	}
+	if req.ReuseExisting != nil {
+		return *req.ReuseExisting
+	}
+	return s.config.DeduplicateURLs
+}
+
+// resolveExpiry turns either an absolute expires_at or a relative ttl
+// (Go duration, e.g. "72h") into the value stored in urls.expires_at.
+// Timestamps are stored as UTC since the column has no time zone.
+func resolveExpiry(req CreateUrlRequest) (pgtype.Timestamp, error) {
+	if req.ExpiresAt != nil && req.TTL != "" {
+		return pgtype.Timestamp{}, errors.New("expires_at and ttl are mutually exclusive")
+	}
+
+	var expiresAt time.Time
+
+	switch {
+	case req.ExpiresAt != nil:
+		expiresAt = *req.ExpiresAt
+	case req.TTL != "":
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			return pgtype.Timestamp{}, errors.New("ttl must be a positive duration such as \"24h\"")
+		}
+		expiresAt = time.Now().Add(ttl)
+	default:
+		return pgtype.Timestamp{}, nil
+	}
+
+	if !expiresAt.After(time.Now()) {
+		return pgtype.Timestamp{}, errors.New("expiration must be in the future")
+	}
+
+	return pgtype.Timestamp{Time: expiresAt.UTC(), Valid: true}, nil
+}
+
+// resolveActiveFrom validates the not-before time of a scheduled link against
+// its (already resolved) expiration.
+func resolveActiveFrom(req CreateUrlRequest, expiresAt pgtype.Timestamp) (pgtype.Timestamp, error) {
+	if req.ActiveFrom == nil {
+		return pgtype.Timestamp{}, nil
+	}
+
+	activeFrom := req.ActiveFrom.UTC()
+	if expiresAt.Valid && !activeFrom.Before(expiresAt.Time) {
+		return pgtype.Timestamp{}, errors.New("active_from must be before the expiration")
+	}
+
+	return pgtype.Timestamp{Time: activeFrom, Valid: true}, nil
+}
+
+func resolveMaxClicks(req CreateUrlRequest) (pgtype.Int8, error) {
+	if req.MaxClicks == nil {
+		return pgtype.Int8{}, nil
+	}
+	if *req.MaxClicks < 1 {
+		return pgtype.Int8{}, errors.New("max_clicks must be at least 1")
+	}
+	return pgtype.Int8{Int64: *req.MaxClicks, Valid: true}, nil
+}
+
+func int8Ptr(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func (s *Server) canonicalize(longUrl string) (string, error) {
+	return utils.CanonicalizeURL(longUrl, utils.CanonicalizeOptions{
+		SortQuery:           s.config.CanonicalSortQuery,
+		StripTrackingParams: s.config.CanonicalStripTracking,
+	})
+}
+
+// generatorFor picks the code generator for a request's code_style.
+func (s *Server) generatorFor(style string) codeGenerator {
+	if style == codeStyleWords {
+		return s.wordCodes
+	}
+	return s.codes
 }
 
-func isValidURL(raw string) bool {
+// aliasError returns the client-facing reason an alias is rejected, or an
+// empty string when it can be used.
+func (s *Server) aliasError(alias string) string {
+	if !utils.ValidateShortCode(alias) {
+		return "Invalid alias format"
+	}
+
+	switch s.codeFilter.Check(alias) {
+	case utils.ErrReservedCode:
+		return "Alias is reserved"
+	case utils.ErrBlockedCode:
+		return "Alias contains a blocked word"
+	}
+	return ""
+}
+
+func isExpired(expiresAt pgtype.Timestamp) bool {
+	return expiresAt.Valid && !time.Now().UTC().Before(expiresAt.Time)
+}
+
+func isScheduled(activeFrom pgtype.Timestamp) bool {
+	return activeFrom.Valid && time.Now().UTC().Before(activeFrom.Time)
+}
+
+const (
+	linkStateScheduled = "scheduled"
+	linkStateLive      = "live"
+	linkStateExpired   = "expired"
+)
+
+func linkState(u db.Url) string {
+	switch {
+	case isExpired(u.ExpiresAt):
+		return linkStateExpired
+	case isScheduled(u.ActiveFrom):
+		return linkStateScheduled
+	default:
+		return linkStateLive
+	}
+}
+
+func timestampPtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
+
+// isValidURL accepts absolute http(s) URLs, plus URLs in any of appSchemes
+// for app deep links.
+func isValidURL(raw string, appSchemes ...string) bool {
 	u, err := url.ParseRequestURI(raw)
 	if err != nil {
 		return false
 	}
 
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return false
+		return slices.Contains(appSchemes, strings.ToLower(u.Scheme))
 	}
 
 	if u.Host == "" {
@@ -50,75 +248,438 @@ func (s *Server) CreateUrl(ctx *gin.Context) {
 		return
 	}
 
-	const (
-		maxRetries = 5
-		codeLen    = 7
-	)
-
 	if !isValidURL(req.LongUrl) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL format"})
 		return
 	}
 
-	var shortCode string
+	if !s.checkDestination(ctx, req.LongUrl) {
+		return
+	}
+
+	targets, ok := s.resolveDeviceTargets(ctx, req)
+	if !ok {
+		return
+	}
+
+	geoRules, ok := s.resolveGeoRules(ctx, req.GeoRules)
+	if !ok {
+		return
+	}
+
+	variants, ok := s.resolveVariants(ctx, req)
+	if !ok {
+		return
+	}
+	mode := ""
+	if len(variants) > 0 {
+		mode = variantMode(req)
+	}
+
+	schedule, timezone, scheduleWarnings, ok := s.resolveSchedule(ctx, req)
+	if !ok {
+		return
+	}
+
+	canonicalUrl, err := s.canonicalize(req.LongUrl)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL format"})
+		return
+	}
+
+	expiresAt, err := resolveExpiry(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activeFrom, err := resolveActiveFrom(req, expiresAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxClicks, err := resolveMaxClicks(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redirectType, redirectWarning, err := resolveRedirectType(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	forwardQuery, err := resolveForwardQuery(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var passwordHash pgtype.Text
+	if req.Password != "" {
+		if msg := validatePassword(req.Password); msg != "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		hashed, err := utils.HashPassword(req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		passwordHash = pgtype.Text{String: hashed, Valid: true}
+	}
+
+	interstitial := s.interstitialReason(req)
+
+	if !interstitial.Valid && s.shouldReuse(req) {
+		existing, err := s.store.GetActiveURLByCanonicalURL(ctx, canonicalUrl)
+		if err == nil {
+			ctx.JSON(http.StatusOK, CreateUrlResponse{
+				ShortUrl:  s.config.BaseURL + "/" + existing.ShortCode,
+				ExpiresAt: timestampPtr(existing.ExpiresAt),
+				Reused:    true,
+			})
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up existing URL"})
+			return
+		}
+	}
+
+	// The link and its routing rules are created together, so the link is
+	// never served, nor reused by the lookup above, without them.
+	params := db.CreateURLWithRulesTxParams{
+		CreateURLParams: db.CreateURLParams{
+			OriginalUrl:        req.LongUrl,
+			CanonicalUrl:       canonicalUrl,
+			ExpiresAt:          expiresAt,
+			PasswordHash:       passwordHash,
+			MaxClicks:          maxClicks,
+			ActiveFrom:         activeFrom,
+			InterstitialReason: interstitial,
+			RedirectType:       redirectType,
+			IosUrl:             targets.ios,
+			AndroidUrl:         targets.android,
+			DesktopUrl:         targets.desktop,
+			AppUrl:             targets.app,
+			ForwardQuery:       forwardQuery,
+			ForwardPath:        req.ForwardPath,
+		},
+		GeoRules:      geoRules,
+		VariantMode:   mode,
+		Variants:      variants,
+		Timezone:      timezone,
+		ScheduleRules: schedule,
+	}
+
+	var created db.Url
+	if req.Alias != "" {
+		if msg := s.aliasError(req.Alias); msg != "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		params.ShortCode = req.Alias
+		created, err = s.store.CreateURLWithRulesTx(ctx, params)
+		if err != nil {
+			if isDuplicateKeyError(err) {
+				ctx.JSON(http.StatusConflict, gin.H{"error": "Alias is already taken"})
+				return
+			}
+			fmt.Println("Error creating URL:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short URL"})
+			return
+		}
+	} else {
+		created, ok = s.createWithGeneratedCode(ctx, req.CodeStyle, params)
+		if !ok {
+			return
+		}
+	}
+
+	response := s.newCreateUrlResponse(created)
+	response.GeoRules = geoRules
+	response.Variants = variantResponses(variants)
+	response.ScheduleRules = scheduleRuleResponses(schedule)
+	response.Warning = redirectWarning
+	response.ScheduleWarnings = scheduleWarnings
+	ctx.JSON(http.StatusOK, response)
+}
+
+// createWithGeneratedCode inserts the link under a generated code, retrying
+// on collisions. It writes an error response and returns false when no code
+// could be found.
+func (s *Server) createWithGeneratedCode(ctx *gin.Context, codeStyle string, params db.CreateURLWithRulesTxParams) (db.Url, bool) {
+	grown := false
+	generator := s.generatorFor(codeStyle)
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		newCode, genErr := utils.GenerateShortCode(codeLen)
+		codes, genErr := generator.Generate(ctx, 1)
 		if genErr != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate short code"})
-			return
+			return db.Url{}, false
 		}
 
-		_, err := s.store.CreateURL(ctx, db.CreateURLParams{
-			OriginalUrl: req.LongUrl,
-			ShortCode:   newCode,
-		})
+		params.ShortCode = codes[0]
+		urlRecord, err := s.store.CreateURLWithRulesTx(ctx, params)
 		if err != nil {
 			if isDuplicateKeyError(err) {
+				generator.Observe(1, 1)
+				// Every attempt collided: the keyspace is crowded at this
+				// length, so grow once and start over instead of failing.
+				if attempt == maxRetries-1 && !grown && generator.Grow() {
+					grown = true
+					attempt = -1
+				}
 				continue
 			}
+			fmt.Println("Error creating URL:", err)
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short URL"})
+			return db.Url{}, false
+		}
+
+		generator.Observe(1, 0)
+		return urlRecord, true
+	}
+
+	ctx.JSON(http.StatusConflict, gin.H{"error": "Could not generate a unique short URL, please retry"})
+	return db.Url{}, false
+}
+
+// newCreateUrlResponse describes a link that was just created. Rules and
+// warnings are not stored on the link and are filled in by the caller.
+func (s *Server) newCreateUrlResponse(urlRecord db.Url) CreateUrlResponse {
+	return CreateUrlResponse{
+		ShortUrl:     s.config.BaseURL + "/" + urlRecord.ShortCode,
+		ActiveFrom:   timestampPtr(urlRecord.ActiveFrom),
+		ExpiresAt:    timestampPtr(urlRecord.ExpiresAt),
+		MaxClicks:    int8Ptr(urlRecord.MaxClicks),
+		Interstitial: urlRecord.InterstitialReason.String,
+		RedirectType: urlRecord.RedirectType.String,
+		IosUrl:       urlRecord.IosUrl.String,
+		AndroidUrl:   urlRecord.AndroidUrl.String,
+		DesktopUrl:   urlRecord.DesktopUrl.String,
+		AppUrl:       urlRecord.AppUrl.String,
+		VariantMode:  urlRecord.VariantMode.String,
+		Timezone:     urlRecord.Timezone.String,
+		ForwardQuery: urlRecord.ForwardQuery.String,
+		ForwardPath:  urlRecord.ForwardPath,
+	}
+}
+
+func (s *Server) RedirectToLongUrl(ctx *gin.Context) {
+	if requestPath(ctx) == "" && isPreviewRequest(ctx.Param("short_code")) {
+		s.PreviewUrl(ctx)
+		return
+	}
+
+	urlRecord, ok := s.findRedirectUrl(ctx, ctx.Param("short_code"))
+	if !ok {
+		return
+	}
+
+	if urlRecord.PasswordHash.Valid && !s.hasUnlockCookie(ctx, urlRecord) {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.HTML(http.StatusOK, "unlock.html", unlockPage{
+			ShortCode: urlRecord.ShortCode,
+			Action:    followUpPath(ctx, urlRecord),
+		})
+		return
+	}
+
+	if s.needsInterstitial(ctx, urlRecord) {
+		s.renderInterstitial(ctx, urlRecord)
+		return
+	}
+
+	if urlRecord.MaxClicks.Valid {
+		claimed, err := s.store.IncrementClickCountWithinLimit(ctx, urlRecord.ShortCode)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
 			return
 		}
+		if claimed == 0 {
+			s.respondClickLimitReached(ctx)
+			return
+		}
+	}
+
+	destination, variant := s.ruleDestination(ctx, urlRecord)
+
+	s.recordClick(ctx, urlRecord, variant)
+
+	destination, appUrl := s.visitorDestination(ctx, urlRecord, destination)
+	if appUrl != "" {
+		s.renderAppLink(ctx, appUrl, destination)
+		return
+	}
+
+	s.redirect(ctx, urlRecord, destination)
+}
+
+// ruleDestination applies the split test or the schedule rules of a link,
+// returning original_url when it has neither, and the label of the variant
+// served, if any.
+func (s *Server) ruleDestination(ctx *gin.Context, urlRecord db.Url) (string, string) {
+	destination := urlRecord.OriginalUrl
+	var variant string
+	if urlRecord.VariantMode.Valid {
+		if chosen := s.pickVariant(ctx, urlRecord); chosen.Label != "" {
+			destination = chosen.Destination
+			variant = chosen.Label
+		}
+		ctx.Header("Cache-Control", "private, no-store")
+	}
+	if urlRecord.ScheduleRouted {
+		destination = s.scheduleDestination(ctx, urlRecord, time.Now())
+		ctx.Header("Cache-Control", "private, no-store")
+	}
+	return destination, variant
+}
+
+// visitorDestination adjusts destination to the visitor: their country's geo
+// rule, their device's destination and the passed-through path and query.
+// appUrl is the deep link to try first, if any.
+func (s *Server) visitorDestination(ctx *gin.Context, urlRecord db.Url, destination string) (string, string) {
+	if urlRecord.GeoTargeted {
+		destination = s.geoDestination(ctx, urlRecord)
+		// The answer depends on where the visitor is: keep it out of shared
+		// caches so a CDN does not serve one country's redirect to all.
+		ctx.Header("Cache-Control", "private")
+		if s.config.CountryHeader != "" {
+			ctx.Writer.Header().Add("Vary", s.config.CountryHeader)
+		}
+	}
 
-		shortCode = newCode
-		break
+	destination, appUrl := deviceDestination(ctx, urlRecord, destination)
+	if isDeviceTargeted(urlRecord) {
+		ctx.Writer.Header().Add("Vary", "User-Agent")
 	}
+	return withPassthrough(ctx, urlRecord, destination), appUrl
+}
+
+func (s *Server) respondNotYetAvailable(ctx *gin.Context, urlRecord db.Url) {
+	ctx.Header("Cache-Control", "no-store")
 
-	if shortCode == "" {
-		ctx.JSON(http.StatusConflict, gin.H{"error": "Could not generate a unique short URL, please retry"})
+	if s.config.ScheduledLinkFallbackURL != "" {
+		ctx.Redirect(http.StatusFound, s.config.ScheduledLinkFallbackURL)
 		return
 	}
 
-	shortUrl := s.config.BaseURL + "/" + shortCode
-	ctx.JSON(http.StatusOK, CreateUrlResponse{ShortUrl: shortUrl})
+	wait := time.Until(urlRecord.ActiveFrom.Time)
+	ctx.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"error":       "Short URL is not yet available",
+		"active_from": urlRecord.ActiveFrom.Time,
+	})
 }
 
-func (s *Server) RedirectToLongUrl(ctx *gin.Context) {
-	shortCode := ctx.Param("short_code")
+func (s *Server) respondClickLimitReached(ctx *gin.Context) {
+	if s.config.ClickLimitFallbackURL != "" {
+		ctx.Redirect(http.StatusFound, s.config.ClickLimitFallbackURL)
+		return
+	}
+	ctx.JSON(http.StatusGone, gin.H{"error": "Short URL has reached its click limit"})
+}
 
+// ensureCaseFoldIndex adds the unique index on lower(short_code) that
+// case-insensitive mode relies on. It refuses to start while codes that only
+// differ in case exist, since one of them could no longer be reached.
+func ensureCaseFoldIndex(store db.Store) error {
+	conflicts, err := store.ListShortCodeCaseConflicts(context.Background())
+	if err != nil {
+		return fmt.Errorf("cannot check short code conflicts: %w", err)
+	}
+	if len(conflicts) > 0 {
+		groups := make([]string, len(conflicts))
+		for i, conflict := range conflicts {
+			groups[i] = strings.Join(conflict.ShortCodes, ", ")
+		}
+		return fmt.Errorf("short codes conflict when case is ignored, rename all but one in each group before enabling CASE_INSENSITIVE_CODES: %s", strings.Join(groups, "; "))
+	}
+
+	if err := store.CreateShortCodeFoldIndex(context.Background()); err != nil {
+		return fmt.Errorf("cannot create case-insensitive short code index: %w", err)
+	}
+	return nil
+}
+
+// getURLByShortCode looks a link up by the code a visitor typed. In
+// case-insensitive mode "ABC" finds "abc"; follow-up queries should use the
+// stored urlRecord.ShortCode.
+func (s *Server) getURLByShortCode(ctx context.Context, shortCode string) (db.Url, error) {
+	if s.config.CaseInsensitiveCodes {
+		return s.store.GetURLByShortCodeFolded(ctx, shortCode)
+	}
+	return s.store.GetURLByShortCode(ctx, shortCode)
+}
+
+// findRedirectUrl loads the link for shortCode and writes the error response
+// itself when the link cannot be followed: it is missing, taken down,
+// expired, not yet active or out of clicks.
+func (s *Server) findRedirectUrl(ctx *gin.Context, shortCode string) (db.Url, bool) {
 	if utils.ValidateShortCode(shortCode) == false {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid short code format"})
-		return
+		return db.Url{}, false
 	}
 
-	urlRecord, err := s.store.GetURLByShortCode(ctx, shortCode)
+	urlRecord, err := s.getURLByShortCode(ctx, shortCode)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
-			return
+			return db.Url{}, false
 		}
 
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL"})
-		return
+		return db.Url{}, false
+	}
+
+	// A path after the code is only meaningful to links that pass it on.
+	if requestPath(ctx) != "" && !urlRecord.ForwardPath {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Short URL not found"})
+		return db.Url{}, false
+	}
+
+	if urlRecord.AbuseStatus.Valid {
+		ctx.JSON(http.StatusGone, gin.H{"error": "Short URL has been suspended for abuse"})
+		return db.Url{}, false
+	}
+
+	if isExpired(urlRecord.ExpiresAt) {
+		ctx.JSON(http.StatusGone, gin.H{"error": "Short URL has expired"})
+		return db.Url{}, false
+	}
+
+	if isScheduled(urlRecord.ActiveFrom) {
+		s.respondNotYetAvailable(ctx, urlRecord)
+		return db.Url{}, false
 	}
 
+	if urlRecord.MaxClicks.Valid && urlRecord.ClickCount.Int64 >= urlRecord.MaxClicks.Int64 {
+		s.respondClickLimitReached(ctx)
+		return db.Url{}, false
+	}
+
+	return urlRecord, true
+}
+
+// recordClick stores the click asynchronously so it never slows the redirect.
+// Request data is read up front because the gin context is recycled once the
+// handler returns. Click-limited links already had their counter claimed
+// atomically before the redirect, so only the click row is written for them.
+// variant is the label of the split-test variant served, if any.
+func (s *Server) recordClick(ctx *gin.Context, urlRecord db.Url, variant string) {
+	clickData := s.extractClickData(ctx)
+	clickData.Variant = pgtype.Text{String: variant, Valid: variant != ""}
+
 	go func() {
 		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		clickData := extractClickData(ctx)
-
 		_, err := s.store.InsertClick(bgCtx, db.InsertClickParams{
 			UrlID:      pgtype.Int8{Int64: urlRecord.ID, Valid: true},
 			IpAddress:  clickData.IpAddress,
@@ -127,6 +688,8 @@ func (s *Server) RedirectToLongUrl(ctx *gin.Context) {
 			Country:    clickData.Country,
 			ClickedAt:  pgtype.Timestamp{Time: time.Now(), Valid: true},
 			DeviceType: clickData.DeviceType,
+			Source:     clickData.Source,
+			Variant:    clickData.Variant,
 		})
 
 		if err != nil {
@@ -134,14 +697,16 @@ func (s *Server) RedirectToLongUrl(ctx *gin.Context) {
 			return
 		}
 
-		err = s.store.IncrementClickCount(bgCtx, shortCode)
+		if urlRecord.MaxClicks.Valid {
+			return
+		}
+
+		err = s.store.IncrementClickCount(bgCtx, urlRecord.ShortCode)
 		if err != nil {
 			fmt.Println("Failed to increment click count:", err)
 			return
 		}
 	}()
-
-	ctx.Redirect(http.StatusFound, urlRecord.OriginalUrl)
 }
 
 func isDuplicateKeyError(err error) bool {
@@ -152,30 +717,52 @@ func isDuplicateKeyError(err error) bool {
 	return false
 }
 
-func extractClickData(ctx *gin.Context) db.InsertClickParams {
+func (s *Server) extractClickData(ctx *gin.Context) db.InsertClickParams {
 	ip := utils.GetClientIP(ctx)
+	source := clickSource(ctx)
+	country := utils.GetCountry(ctx, s.config.CountryHeader)
 
 	return db.InsertClickParams{
 		IpAddress:  pgtype.Text{String: ip, Valid: true},
 		UserAgent:  pgtype.Text{String: ctx.Request.UserAgent(), Valid: true},
 		Referer:    pgtype.Text{String: ctx.Request.Referer(), Valid: true},
-		Country:    pgtype.Text{String: utils.GetCountryFromIP(ip), Valid: true},
+		Country:    pgtype.Text{String: country, Valid: country != ""},
 		DeviceType: pgtype.Text{String: utils.DetectDeviceType(ctx.Request.UserAgent()), Valid: true},
+		Source:     pgtype.Text{String: source, Valid: source != ""},
 	}
 }
 
 type GetListUrlsRequest struct {
-	Limit int32 `json:"limit" form:"limit,default=10"`
-	Page  int32 `json:"page" form:"page,default=0"`
+	Limit int32  `json:"limit" form:"limit,default=10"`
+	Page  int32  `json:"page" form:"page,default=0"`
+	State string `json:"state" form:"state" binding:"omitempty,oneof=scheduled live expired"`
 }
 
 type UrlResponse struct {
-	Id          int64            `json:"id"`
-	OriginalUrl string           `json:"original_url"`
-	ShortCode   string           `json:"short_code"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
-	ClickCount  int64            `json:"click_count"`
-	TinyUrl     string           `json:"tiny_url"`
+	Id             int64            `json:"id"`
+	OriginalUrl    string           `json:"original_url,omitempty"`
+	CanonicalUrl   string           `json:"canonical_url,omitempty"`
+	ShortCode      string           `json:"short_code"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	ClickCount     int64            `json:"click_count"`
+	TinyUrl        string           `json:"tiny_url"`
+	ActiveFrom     pgtype.Timestamp `json:"active_from"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+	IsExpired      bool             `json:"is_expired"`
+	State          string           `json:"state"`
+	HasPassword    bool             `json:"has_password"`
+	MaxClicks      pgtype.Int8      `json:"max_clicks"`
+	RedirectType   pgtype.Text      `json:"redirect_type"`
+	IosUrl         pgtype.Text      `json:"ios_url"`
+	AndroidUrl     pgtype.Text      `json:"android_url"`
+	DesktopUrl     pgtype.Text      `json:"desktop_url"`
+	AppUrl         pgtype.Text      `json:"app_url"`
+	ForwardQuery   pgtype.Text      `json:"forward_query"`
+	ForwardPath    bool             `json:"forward_path"`
+	GeoTargeted    bool             `json:"geo_targeted"`
+	VariantMode    pgtype.Text      `json:"variant_mode"`
+	ScheduleRouted bool             `json:"schedule_routed"`
+	Timezone       pgtype.Text      `json:"timezone"`
 }
 
 type GetListUrlsResponse struct {
@@ -196,8 +783,10 @@ func (s *Server) GetListUrls(ctx *gin.Context) {
 	}
 
 	offset := req.Page * req.Limit
+	state := pgtype.Text{String: req.State, Valid: req.State != ""}
 
 	urls, err := s.store.ListURLs(ctx, db.ListURLsParams{
+		State:  state,
 		Limit:  req.Limit,
 		Offset: offset,
 	})
@@ -207,7 +796,7 @@ func (s *Server) GetListUrls(ctx *gin.Context) {
 		return
 	}
 
-	total, err := s.store.CountURLs(ctx)
+	total, err := s.store.CountURLs(ctx, state)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL count"})
 		return
@@ -216,12 +805,40 @@ func (s *Server) GetListUrls(ctx *gin.Context) {
 	content := make([]UrlResponse, len(urls))
 	for i, u := range urls {
 		content[i] = UrlResponse{
-			Id:          u.ID,
-			OriginalUrl: u.OriginalUrl,
-			ShortCode:   u.ShortCode,
-			CreatedAt:   u.CreatedAt,
-			ClickCount:  u.ClickCount.Int64,
-			TinyUrl:     s.config.BaseURL + "/" + u.ShortCode,
+			Id:             u.ID,
+			OriginalUrl:    u.OriginalUrl,
+			CanonicalUrl:   u.CanonicalUrl,
+			ShortCode:      u.ShortCode,
+			CreatedAt:      u.CreatedAt,
+			ClickCount:     u.ClickCount.Int64,
+			TinyUrl:        s.config.BaseURL + "/" + u.ShortCode,
+			ActiveFrom:     u.ActiveFrom,
+			ExpiresAt:      u.ExpiresAt,
+			IsExpired:      isExpired(u.ExpiresAt),
+			State:          linkState(u),
+			HasPassword:    u.PasswordHash.Valid,
+			MaxClicks:      u.MaxClicks,
+			RedirectType:   u.RedirectType,
+			IosUrl:         u.IosUrl,
+			AndroidUrl:     u.AndroidUrl,
+			DesktopUrl:     u.DesktopUrl,
+			AppUrl:         u.AppUrl,
+			ForwardQuery:   u.ForwardQuery,
+			ForwardPath:    u.ForwardPath,
+			GeoTargeted:    u.GeoTargeted,
+			VariantMode:    u.VariantMode,
+			ScheduleRouted: u.ScheduleRouted,
+			Timezone:       u.Timezone,
+		}
+		// The list is public: like the preview page, it must not reveal
+		// where a password-protected link goes.
+		if u.PasswordHash.Valid {
+			content[i].OriginalUrl = ""
+			content[i].CanonicalUrl = ""
+			content[i].IosUrl = pgtype.Text{}
+			content[i].AndroidUrl = pgtype.Text{}
+			content[i].DesktopUrl = pgtype.Text{}
+			content[i].AppUrl = pgtype.Text{}
 		}
 	}
 
@@ -262,6 +879,8 @@ type UrlStats struct {
 	Referer    pgtype.Text      `json:"referer"`
 	DeviceType pgtype.Text      `json:"device_type"`
 	Country    pgtype.Text      `json:"country"`
+	Source     pgtype.Text      `json:"source"`
+	Variant    pgtype.Text      `json:"variant"`
 }
 
 func (s *Server) GetUrlStats(ctx *gin.Context) {
@@ -300,6 +919,8 @@ func (s *Server) GetUrlStats(ctx *gin.Context) {
 			Referer:    s.Referer,
 			DeviceType: s.DeviceType,
 			Country:    s.Country,
+			Source:     s.Source,
+			Variant:    s.Variant,
 		}
 	}
 
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	variantModeRandom = "random"
+	variantModeSticky = "sticky"
+
+	minVariants      = 2
+	maxVariants      = 10
+	maxVariantWeight = 10000
+
+	variantCookiePrefix = "variant_"
+	variantCookieTTL    = 30 * 24 * time.Hour
+)
+
+var variantLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// VariantRequest is one destination of a split test. Weights are relative:
+// 70 and 30 send 70% and 30% of visits. Labels default to A, B, C...
+type VariantRequest struct {
+	Label  string `json:"label"`
+	Url    string `json:"url"`
+	Weight int32  `json:"weight"`
+}
+
+type VariantResponse struct {
+	Label       string `json:"label"`
+	Destination string `json:"destination"`
+	Weight      int32  `json:"weight"`
+}
+
+// VariantStats leaves the destination out: the stats endpoints are public
+// and the link may be password-protected.
+type VariantStats struct {
+	Label      string `json:"label"`
+	Weight     int32  `json:"weight"`
+	ClickCount int64  `json:"click_count"`
+}
+
+// resolveVariants validates the split-test destinations of a request. They
+// pass the same destination policy as long_url, and a visit has to be routed
+// by exactly one rule, so split tests cannot be mixed with device targets or
+// geo rules. It writes a 400 response and returns false when refused.
+func (s *Server) resolveVariants(ctx *gin.Context, req CreateUrlRequest) ([]db.UrlVariant, bool) {
+	if len(req.Variants) == 0 {
+		if req.VariantMode != "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "variant_mode requires variants"})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if len(req.Variants) < minVariants || len(req.Variants) > maxVariants {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("A split test needs between %d and %d variants", minVariants, maxVariants)})
+		return nil, false
+	}
+	if hasDeviceTargets(req) || len(req.GeoRules) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Split tests cannot be combined with device targets or geo rules"})
+		return nil, false
+	}
+
+	variants := make([]db.UrlVariant, len(req.Variants))
+	seen := make(map[string]bool, len(req.Variants))
+	for i, variant := range req.Variants {
+		label := strings.TrimSpace(variant.Label)
+		if label == "" {
+			label = string(rune('A' + i))
+		}
+		if !variantLabelPattern.MatchString(label) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid variant label %q, use up to 32 letters, digits, - or _", label)})
+			return nil, false
+		}
+		if seen[label] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Duplicate variant label %s", label)})
+			return nil, false
+		}
+		seen[label] = true
+
+		weight := variant.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if weight < 0 || weight > maxVariantWeight {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Weight of variant %s must be between 1 and %d", label, maxVariantWeight)})
+			return nil, false
+		}
+
+		if !isValidURL(variant.Url) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid URL for variant %s", label)})
+			return nil, false
+		}
+		if !s.checkDestination(ctx, variant.Url) {
+			return nil, false
+		}
+
+		variants[i] = db.UrlVariant{Label: label, Destination: variant.Url, Weight: weight}
+	}
+	return variants, true
+}
+
+func variantMode(req CreateUrlRequest) string {
+	if req.VariantMode == "" {
+		return variantModeRandom
+	}
+	return req.VariantMode
+}
+
+func variantResponses(variants []db.UrlVariant) []VariantResponse {
+	response := make([]VariantResponse, len(variants))
+	for i, variant := range variants {
+		response[i] = VariantResponse{Label: variant.Label, Destination: variant.Destination, Weight: variant.Weight}
+	}
+	return response
+}
+
+// pickVariant chooses the variant for this visit, weighted at random. In
+// sticky mode a visitor who was already assigned one keeps it as long as it
+// still exists. It returns the zero variant, and the caller falls back to
+// original_url, when the variants cannot be loaded or carry no weight.
+func (s *Server) pickVariant(ctx *gin.Context, urlRecord db.Url) db.UrlVariant {
+	variants, err := s.store.ListVariantsByURLID(ctx, urlRecord.ID)
+	if err != nil || len(variants) == 0 {
+		if err != nil {
+			log.Println("failed to load variants:", err)
+		}
+		return db.UrlVariant{}
+	}
+
+	sticky := urlRecord.VariantMode.String == variantModeSticky
+	cookieName := variantCookiePrefix + urlRecord.ShortCode
+	if sticky {
+		if label, err := ctx.Cookie(cookieName); err == nil {
+			for _, variant := range variants {
+				if variant.Label == label {
+					return variant
+				}
+			}
+		}
+	}
+
+	var total int64
+	for _, variant := range variants {
+		total += int64(variant.Weight)
+	}
+	if total <= 0 {
+		return db.UrlVariant{}
+	}
+	n := rand.Int64N(total)
+	chosen := variants[len(variants)-1]
+	for _, variant := range variants {
+		if n < int64(variant.Weight) {
+			chosen = variant
+			break
+		}
+		n -= int64(variant.Weight)
+	}
+
+	if sticky {
+		ctx.SetSameSite(http.SameSiteLaxMode)
+		ctx.SetCookie(
+			cookieName,
+			chosen.Label,
+			int(variantCookieTTL.Seconds()),
+			"/",
+			"",
+			strings.HasPrefix(s.config.BaseURL, "https://"),
+			true,
+		)
+	}
+	return chosen
+}
+
+// GetUrlVariantStats breaks the clicks of a split-test link down by the
+// variant that was served.
+func (s *Server) GetUrlVariantStats(ctx *gin.Context) {
+	urlID, err := strconv.ParseInt(ctx.Param("url_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL ID"})
+		return
+	}
+
+	variants, err := s.store.ListVariantsByURLID(ctx, urlID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve variants"})
+		return
+	}
+
+	counts, err := s.store.CountClicksByVariant(ctx, pgtype.Int8{Int64: urlID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve URL stats"})
+		return
+	}
+
+	clicks := make(map[string]int64, len(counts))
+	var total int64
+	for _, count := range counts {
+		clicks[count.Variant.String] = count.ClickCount
+		total += count.ClickCount
+	}
+
+	content := make([]VariantStats, len(variants))
+	for i, variant := range variants {
+		content[i] = VariantStats{Label: variant.Label, Weight: variant.Weight, ClickCount: clicks[variant.Label]}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"content":     content,
+		"total_count": total,
+	})
+}
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+ALTER COLUMN short_code TYPE VARCHAR(32);
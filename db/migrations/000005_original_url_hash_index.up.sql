@@ -0,0 +1 @@
+CREATE INDEX idx_original_url_hash ON urls USING HASH (original_url);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_canonical_url_hash;
+CREATE INDEX idx_original_url_hash ON urls USING HASH (original_url);
+
+ALTER TABLE urls
+DROP COLUMN canonical_url;
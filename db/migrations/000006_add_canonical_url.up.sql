@@ -0,0 +1,10 @@
+ALTER TABLE urls
+ADD COLUMN canonical_url TEXT;
+
+UPDATE urls SET canonical_url = original_url;
+
+ALTER TABLE urls
+ALTER COLUMN canonical_url SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_original_url_hash;
+CREATE INDEX idx_canonical_url_hash ON urls USING HASH (canonical_url);
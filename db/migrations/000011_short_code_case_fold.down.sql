@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS urls_short_code_lower_idx;
+DROP INDEX IF EXISTS urls_short_code_lower_key;
@@ -0,0 +1,5 @@
+-- Case-insensitive mode (CASE_INSENSITIVE_CODES) looks codes up by
+-- lower(short_code). The unique index on it is only created when the mode is
+-- switched on (see ensureCaseFoldIndex), so existing codes that differ only
+-- in case never block the migration.
+CREATE INDEX IF NOT EXISTS urls_short_code_lower_idx ON urls (lower(short_code));
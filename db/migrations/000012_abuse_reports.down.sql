@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS banned_domains;
+DROP TABLE IF EXISTS abuse_reports;
+ALTER TABLE urls
+DROP COLUMN abuse_status;
@@ -0,0 +1,27 @@
+-- abuse_status is NULL for normal links. 'suspended' links were taken down
+-- automatically or by a moderator and can be reinstated; 'banned' ones stay
+-- down for good. Both also have is_active = false.
+ALTER TABLE urls
+ADD COLUMN abuse_status VARCHAR(16);
+
+CREATE TABLE abuse_reports (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT,
+    reporter_ip VARCHAR(45) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX idx_abuse_reports_url_id ON abuse_reports (url_id);
+-- One open report per reporter and link, so a single visitor cannot push a
+-- link over the suspension threshold.
+CREATE UNIQUE INDEX abuse_reports_open_reporter_key ON abuse_reports (url_id, reporter_ip) WHERE status = 'open';
+
+CREATE TABLE banned_domains (
+    domain VARCHAR(255) PRIMARY KEY,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
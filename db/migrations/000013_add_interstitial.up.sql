@@ -0,0 +1,4 @@
+-- Links with an interstitial_reason ('manual', 'policy' or 'reports') show a
+-- warning page with the destination before redirecting.
+ALTER TABLE urls
+ADD COLUMN interstitial_reason VARCHAR(16);
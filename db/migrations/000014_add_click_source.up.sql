@@ -0,0 +1,4 @@
+-- Where the click came from when the link says so, e.g. 'qr' for scans of a
+-- generated QR code. NULL for ordinary clicks.
+ALTER TABLE clicks
+ADD COLUMN source VARCHAR(16);
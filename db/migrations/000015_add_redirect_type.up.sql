@@ -0,0 +1,4 @@
+-- How the link redirects: an HTTP status ('301', '302', '307', '308') or an
+-- HTML page that hides the Referer ('meta' refresh or 'js'). NULL means 302.
+ALTER TABLE urls
+ADD COLUMN redirect_type VARCHAR(8);
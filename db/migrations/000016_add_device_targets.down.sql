@@ -0,0 +1,5 @@
+ALTER TABLE urls
+DROP COLUMN ios_url,
+DROP COLUMN android_url,
+DROP COLUMN desktop_url,
+DROP COLUMN app_url;
@@ -0,0 +1,9 @@
+-- Optional per-platform destinations. Visitors on iOS, Android or desktop go
+-- to their platform's URL when one is set, otherwise to original_url. On
+-- phones app_url (a deep link with an allowlisted scheme) is tried first and
+-- the platform URL, usually the app store page, is the fallback.
+ALTER TABLE urls
+ADD COLUMN ios_url TEXT,
+ADD COLUMN android_url TEXT,
+ADD COLUMN desktop_url TEXT,
+ADD COLUMN app_url TEXT;
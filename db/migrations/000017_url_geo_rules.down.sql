@@ -0,0 +1,4 @@
+ALTER TABLE urls
+DROP COLUMN geo_targeted;
+
+DROP TABLE IF EXISTS url_geo_rules;
@@ -0,0 +1,14 @@
+-- Country-specific destinations. Visitors from a listed country go to its
+-- destination, everyone else to original_url. geo_targeted mirrors whether a
+-- link has any rules so redirects without rules skip the lookup.
+CREATE TABLE url_geo_rules (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    country_code CHAR(2) NOT NULL,
+    destination TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (url_id, country_code)
+);
+
+ALTER TABLE urls
+ADD COLUMN geo_targeted BOOLEAN NOT NULL DEFAULT false;
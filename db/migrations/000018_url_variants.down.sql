@@ -0,0 +1,7 @@
+ALTER TABLE clicks
+DROP COLUMN variant;
+
+ALTER TABLE urls
+DROP COLUMN variant_mode;
+
+DROP TABLE IF EXISTS url_variants;
@@ -0,0 +1,19 @@
+-- A/B split destinations. A link with variant_mode set ('random' or
+-- 'sticky') sends each visit to one of its variants, picked by weight;
+-- 'sticky' keeps a visitor on the same variant through a cookie. The
+-- variant served is recorded in clicks.variant.
+CREATE TABLE url_variants (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    label VARCHAR(32) NOT NULL,
+    destination TEXT NOT NULL,
+    weight INTEGER NOT NULL CHECK (weight > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (url_id, label)
+);
+
+ALTER TABLE urls
+ADD COLUMN variant_mode VARCHAR(8);
+
+ALTER TABLE clicks
+ADD COLUMN variant VARCHAR(32);
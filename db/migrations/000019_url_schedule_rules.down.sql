@@ -0,0 +1,5 @@
+ALTER TABLE urls
+DROP COLUMN schedule_routed,
+DROP COLUMN timezone;
+
+DROP TABLE IF EXISTS url_schedule_rules;
@@ -0,0 +1,24 @@
+-- Time-based routing. Rules are checked in position order in the link's
+-- timezone and the first one matching the visit time picks the destination;
+-- urls.schedule_routed marks links that have any.
+CREATE TABLE url_schedule_rules (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    -- One bit per weekday, bit 0 = Sunday. 127 = every day.
+    days SMALLINT NOT NULL DEFAULT 127 CHECK (days BETWEEN 1 AND 127),
+    -- Minutes since local midnight, end exclusive.
+    start_minute SMALLINT CHECK (start_minute BETWEEN 0 AND 1439),
+    end_minute SMALLINT CHECK (end_minute BETWEEN 1 AND 1440),
+    -- Inclusive local dates.
+    start_date DATE,
+    end_date DATE,
+    destination TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (url_id, position),
+    CHECK ((start_minute IS NULL) = (end_minute IS NULL))
+);
+
+ALTER TABLE urls
+ADD COLUMN timezone VARCHAR(64),
+ADD COLUMN schedule_routed BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+ALTER TABLE urls
+DROP COLUMN forward_path,
+DROP COLUMN forward_query;
@@ -0,0 +1,7 @@
+-- Per-link passthrough on redirect. forward_query is NULL (off), 'merge'
+-- (the destination's own parameters win) or 'override' (the visitor's
+-- win); forward_path appends whatever follows the short code to the
+-- destination path.
+ALTER TABLE urls
+ADD COLUMN forward_query VARCHAR(8),
+ADD COLUMN forward_path BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,8 @@
+-- Code length each generator has grown to ('random', 'sequence', or 'words'
+-- for the digits of the word code suffix), so a restart does not go back to
+-- the configured length and its collisions. Lengths only ever go up.
+CREATE TABLE code_lengths (
+    generator VARCHAR(16) PRIMARY KEY,
+    length INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
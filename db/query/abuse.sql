@@ -0,0 +1,59 @@
+-- db/queries/abuse.sql
+
+-- name: CreateAbuseReport :one
+INSERT INTO abuse_reports (url_id, reason, details, reporter_ip)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (url_id, reporter_ip) WHERE status = 'open' DO NOTHING
+RETURNING *;
+
+-- name: CountOpenAbuseReports :one
+SELECT COUNT(*) AS report_count
+FROM abuse_reports
+WHERE url_id = $1 AND status = 'open';
+
+-- name: ListAbuseReportsByURLID :many
+SELECT * FROM abuse_reports
+WHERE url_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListReportedURLs :many
+SELECT
+    u.id,
+    u.short_code,
+    u.original_url,
+    u.is_active,
+    u.abuse_status,
+    COUNT(r.id) AS report_count,
+    MAX(r.created_at)::timestamp AS last_reported_at,
+    array_remove(array_agg(DISTINCT r.reason), NULL)::text[] AS reasons
+FROM urls u
+LEFT JOIN abuse_reports r ON r.url_id = u.id AND r.status = 'open'
+WHERE r.id IS NOT NULL OR u.abuse_status = 'threat_feed'
+GROUP BY u.id
+ORDER BY report_count DESC, last_reported_at DESC NULLS LAST
+LIMIT $1 OFFSET $2;
+
+-- name: CountReportedURLs :one
+SELECT COUNT(*) AS url_count
+FROM urls u
+WHERE u.abuse_status = 'threat_feed'
+   OR EXISTS (SELECT 1 FROM abuse_reports r WHERE r.url_id = u.id AND r.status = 'open');
+
+-- name: ResolveAbuseReports :execrows
+UPDATE abuse_reports
+SET status = sqlc.arg(status), resolved_at = NOW()
+WHERE url_id = sqlc.arg(url_id) AND status = 'open';
+
+-- name: CreateBannedDomain :one
+INSERT INTO banned_domains (domain, reason)
+VALUES ($1, $2)
+ON CONFLICT (domain) DO UPDATE SET reason = EXCLUDED.reason
+RETURNING *;
+
+-- name: DeleteBannedDomain :execrows
+DELETE FROM banned_domains
+WHERE domain = $1;
+
+-- name: ListBannedDomains :many
+SELECT * FROM banned_domains
+ORDER BY domain;
@@ -1,7 +1,7 @@
 
 -- name: InsertClick :one
-INSERT INTO clicks (url_id, ip_address, clicked_at, user_agent, referer, device_type, country)
-VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+INSERT INTO clicks (url_id, ip_address, clicked_at, user_agent, referer, device_type, country, source, variant)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;
 
 -- name: GetClicksByURLID :many
 
@@ -14,4 +14,11 @@ LIMIT $2 OFFSET $3;
 -- name: CountClicksByURLID :one
 SELECT COUNT(*) AS click_count
 FROM clicks
-WHERE url_id = $1;
\ No newline at end of file
+WHERE url_id = $1;
+
+-- name: CountClicksByVariant :many
+SELECT variant, COUNT(*) AS click_count
+FROM clicks
+WHERE url_id = $1 AND variant IS NOT NULL
+GROUP BY variant
+ORDER BY variant;
\ No newline at end of file
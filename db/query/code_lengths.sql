@@ -0,0 +1,11 @@
+-- db/queries/code_lengths.sql
+
+-- name: GetCodeLength :one
+SELECT length FROM code_lengths
+WHERE generator = $1;
+
+-- name: RaiseCodeLength :exec
+INSERT INTO code_lengths (generator, length)
+VALUES ($1, $2)
+ON CONFLICT (generator) DO UPDATE
+SET length = GREATEST(code_lengths.length, EXCLUDED.length), updated_at = NOW();
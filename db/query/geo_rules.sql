@@ -0,0 +1,21 @@
+-- db/queries/geo_rules.sql
+
+-- name: CreateGeoRules :exec
+INSERT INTO url_geo_rules (url_id, country_code, destination)
+SELECT
+    sqlc.arg(url_id),
+    unnest(sqlc.arg(country_codes)::char(2)[]),
+    unnest(sqlc.arg(destinations)::text[]);
+
+-- name: DeleteGeoRulesByURLID :exec
+DELETE FROM url_geo_rules
+WHERE url_id = $1;
+
+-- name: GetGeoRuleDestination :one
+SELECT destination FROM url_geo_rules
+WHERE url_id = $1 AND country_code = $2;
+
+-- name: ListGeoRulesByURLID :many
+SELECT * FROM url_geo_rules
+WHERE url_id = $1
+ORDER BY country_code;
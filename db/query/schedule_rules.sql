@@ -0,0 +1,14 @@
+-- db/queries/schedule_rules.sql
+
+-- name: CreateScheduleRule :exec
+INSERT INTO url_schedule_rules (url_id, position, days, start_minute, end_minute, start_date, end_date, destination)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: DeleteScheduleRulesByURLID :exec
+DELETE FROM url_schedule_rules
+WHERE url_id = $1;
+
+-- name: ListScheduleRulesByURLID :many
+SELECT * FROM url_schedule_rules
+WHERE url_id = $1
+ORDER BY position;
@@ -14,7 +14,9 @@ WHERE DATE(clicked_at) = CURRENT_DATE;
 SELECT 
     u.short_code,
     u.original_url,
-    u.click_count
+    u.click_count,
+    u.expires_at
 FROM urls u
+WHERE u.password_hash IS NULL AND u.is_active = true AND u.abuse_status IS NULL
 ORDER BY u.click_count DESC
 LIMIT $1;
\ No newline at end of file
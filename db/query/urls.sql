@@ -1,32 +1,123 @@
 -- db/queries/urls.sql
 
 -- name: CreateURL :one
-INSERT INTO urls (short_code, original_url)
-VALUES ($1, $2)
+INSERT INTO urls (short_code, original_url, expires_at, canonical_url, password_hash, max_clicks, active_from, interstitial_reason, redirect_type,
+    ios_url, android_url, desktop_url, app_url, forward_query, forward_path)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 RETURNING *;
 
+-- name: CreateURLsBatch :many
+INSERT INTO urls (short_code, original_url, expires_at, canonical_url, max_clicks, active_from, interstitial_reason, redirect_type,
+    forward_query, forward_path)
+SELECT
+    unnest(sqlc.arg(short_codes)::varchar[]),
+    unnest(sqlc.arg(original_urls)::text[]),
+    unnest(sqlc.arg(expires_ats)::timestamp[]),
+    unnest(sqlc.arg(canonical_urls)::text[]),
+    unnest(sqlc.arg(max_clicks)::bigint[]),
+    unnest(sqlc.arg(active_froms)::timestamp[]),
+    unnest(sqlc.arg(interstitial_reasons)::varchar[]),
+    unnest(sqlc.arg(redirect_types)::varchar[]),
+    unnest(sqlc.arg(forward_queries)::varchar[]),
+    unnest(sqlc.arg(forward_paths)::boolean[])
+ON CONFLICT DO NOTHING
+RETURNING short_code;
+
 -- name: GetURLByShortCode :one
 SELECT * FROM urls
-WHERE short_code = $1 AND is_active = true
+WHERE short_code = $1 AND (is_active = true OR abuse_status IS NOT NULL OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
 LIMIT 1;
 
+-- name: GetURLByShortCodeFolded :one
+SELECT * FROM urls
+WHERE lower(short_code) = lower($1) AND (is_active = true OR abuse_status IS NOT NULL OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+LIMIT 1;
+
+-- name: GetActiveURLByCanonicalURL :one
+SELECT * FROM urls
+WHERE canonical_url = $1 AND is_active = true
+  AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+  AND password_hash IS NULL AND max_clicks IS NULL AND active_from IS NULL
+  AND interstitial_reason IS NULL AND redirect_type IS NULL
+  AND ios_url IS NULL AND android_url IS NULL AND desktop_url IS NULL AND app_url IS NULL
+  AND geo_targeted = false AND variant_mode IS NULL AND schedule_routed = false
+  AND forward_query IS NULL AND forward_path = false
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListActiveURLsByCanonicalURLs :many
+SELECT DISTINCT ON (canonical_url) short_code, canonical_url, expires_at
+FROM urls
+WHERE canonical_url = ANY(sqlc.arg(canonical_urls)::text[]) AND is_active = true
+  AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+  AND password_hash IS NULL AND max_clicks IS NULL AND active_from IS NULL
+  AND interstitial_reason IS NULL AND redirect_type IS NULL
+  AND ios_url IS NULL AND android_url IS NULL AND desktop_url IS NULL AND app_url IS NULL
+  AND geo_targeted = false AND variant_mode IS NULL AND schedule_routed = false
+  AND forward_query IS NULL AND forward_path = false
+ORDER BY canonical_url, created_at DESC;
+
 -- name: IncrementClickCount :exec
 UPDATE urls
 SET click_count = click_count + 1
 WHERE short_code = $1;
 
+-- name: IncrementClickCountWithinLimit :execrows
+UPDATE urls
+SET click_count = click_count + 1
+WHERE short_code = $1 AND (max_clicks IS NULL OR click_count < max_clicks);
+
+-- name: ListActiveURLsAfterID :many
+SELECT
+    u.id,
+    u.short_code,
+    array_remove(
+        ARRAY[u.original_url, u.ios_url, u.android_url, u.desktop_url]
+        || ARRAY(SELECT g.destination FROM url_geo_rules g WHERE g.url_id = u.id)
+        || ARRAY(SELECT v.destination FROM url_variants v WHERE v.url_id = u.id)
+        || ARRAY(SELECT r.destination FROM url_schedule_rules r WHERE r.url_id = u.id),
+        NULL
+    )::text[] AS destinations
+FROM urls u
+WHERE u.is_active = true AND u.id > $1
+ORDER BY u.id
+LIMIT $2;
+
 -- name: ListURLs :many
 SELECT * FROM urls
-WHERE is_active = true
+WHERE (is_active = true OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+  AND (
+    sqlc.narg(state)::text IS NULL
+    OR (sqlc.narg(state) = 'scheduled' AND active_from > (NOW() AT TIME ZONE 'UTC'))
+    OR (sqlc.narg(state) = 'expired' AND expires_at <= (NOW() AT TIME ZONE 'UTC'))
+    OR (sqlc.narg(state) = 'live'
+        AND (active_from IS NULL OR active_from <= (NOW() AT TIME ZONE 'UTC'))
+        AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC')))
+  )
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListUncanonicalizedURLsAfterID :many
+SELECT id, original_url
+FROM urls
+WHERE is_active = true AND canonical_url = original_url AND id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: SetURLCanonical :exec
+UPDATE urls
+SET canonical_url = $2
+WHERE id = $1;
 
 -- name: GetURLStats :one
 SELECT 
     short_code,
     original_url,
     click_count,
-    created_at
+    created_at,
+    expires_at,
+    active_from,
+    password_hash IS NOT NULL AS has_password
 FROM urls
 WHERE short_code = $1 AND is_active = true
 LIMIT 1;
@@ -38,10 +129,122 @@ SELECT EXISTS(
 
 -- name: DeactivateURL :exec
 UPDATE urls
-SET is_active = false
+SET is_active = false, updated_at = NOW()
 WHERE short_code = $1;
 
+-- name: SuspendURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'suspended', updated_at = NOW()
+WHERE id = $1 AND is_active = true AND abuse_status IS NULL;
+
+-- name: QuarantineURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'threat_feed', updated_at = NOW()
+WHERE id = $1 AND abuse_status IS NULL;
+
+-- name: BanURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'banned', updated_at = NOW()
+WHERE id = $1;
+
+-- name: BanURLsByDomain :execrows
+UPDATE urls u
+SET is_active = false, abuse_status = 'banned', updated_at = NOW()
+WHERE u.abuse_status IS DISTINCT FROM 'banned'
+  AND EXISTS (
+    SELECT 1
+    FROM unnest(
+        ARRAY[u.original_url, u.ios_url, u.android_url, u.desktop_url]
+        || ARRAY(SELECT g.destination FROM url_geo_rules g WHERE g.url_id = u.id)
+        || ARRAY(SELECT v.destination FROM url_variants v WHERE v.url_id = u.id)
+        || ARRAY(SELECT r.destination FROM url_schedule_rules r WHERE r.url_id = u.id)
+    ) AS d(destination),
+    LATERAL (SELECT lower(substring(d.destination FROM '^[a-zA-Z]+://(?:[^@/]*@)?([^/:?#]+)')) AS host) h
+    WHERE h.host = sqlc.arg(domain)::text OR h.host LIKE '%.' || sqlc.arg(domain)::text
+  );
+
+-- name: ReinstateURL :execrows
+UPDATE urls
+SET is_active = true, abuse_status = NULL, updated_at = NOW()
+WHERE id = $1 AND abuse_status IN ('suspended', 'threat_feed');
+
+-- name: SetURLInterstitial :execrows
+UPDATE urls
+SET interstitial_reason = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: FlagURLInterstitial :execrows
+UPDATE urls
+SET interstitial_reason = $2, updated_at = NOW()
+WHERE id = $1 AND interstitial_reason IS NULL;
+
 -- name: CountURLs :one
 SELECT COUNT(*) AS url_count
 FROM urls
-WHERE is_active = true;
\ No newline at end of file
+WHERE (is_active = true OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+  AND (
+    sqlc.narg(state)::text IS NULL
+    OR (sqlc.narg(state) = 'scheduled' AND active_from > (NOW() AT TIME ZONE 'UTC'))
+    OR (sqlc.narg(state) = 'expired' AND expires_at <= (NOW() AT TIME ZONE 'UTC'))
+    OR (sqlc.narg(state) = 'live'
+        AND (active_from IS NULL OR active_from <= (NOW() AT TIME ZONE 'UTC'))
+        AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC')))
+  );
+
+-- name: CountExpiredURLs :one
+SELECT COUNT(*) AS url_count
+FROM urls
+WHERE expires_at IS NOT NULL AND expires_at <= (NOW() AT TIME ZONE 'UTC');
+
+-- name: DeactivateExpiredURLs :many
+UPDATE urls
+SET is_active = false, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM urls
+    WHERE is_active = true AND expires_at IS NOT NULL AND expires_at <= (NOW() AT TIME ZONE 'UTC')
+    ORDER BY expires_at
+    LIMIT $1
+)
+RETURNING short_code;
+
+-- name: DeleteInactiveURLs :execrows
+DELETE FROM urls
+WHERE id IN (
+    SELECT id FROM urls
+    WHERE is_active = false AND abuse_status IS NULL AND updated_at < NOW() - sqlc.arg(retention)::interval
+    LIMIT sqlc.arg(batch_size)::int
+);
+
+-- name: TryAdvisoryXactLock :one
+SELECT pg_try_advisory_xact_lock(sqlc.arg(lock_id)::bigint) AS locked;
+
+-- name: NextShortCodeIDs :many
+SELECT nextval('short_code_seq')::bigint AS id
+FROM generate_series(1, sqlc.arg(count)::int);
+
+-- name: ListShortCodeCaseConflicts :many
+SELECT
+    lower(short_code)::text AS folded_code,
+    array_agg(short_code ORDER BY created_at)::varchar[] AS short_codes
+FROM urls
+GROUP BY lower(short_code)
+HAVING count(*) > 1
+ORDER BY folded_code;
+
+-- name: CreateShortCodeFoldIndex :exec
+CREATE UNIQUE INDEX IF NOT EXISTS urls_short_code_lower_key ON urls (lower(short_code));
+
+-- name: SetURLGeoTargeted :exec
+UPDATE urls
+SET geo_targeted = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetURLVariantMode :exec
+UPDATE urls
+SET variant_mode = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetURLSchedule :exec
+UPDATE urls
+SET schedule_routed = $2, timezone = $3, updated_at = NOW()
+WHERE id = $1;
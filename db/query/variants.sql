@@ -0,0 +1,18 @@
+-- db/queries/variants.sql
+
+-- name: CreateVariants :exec
+INSERT INTO url_variants (url_id, label, destination, weight)
+SELECT
+    sqlc.arg(url_id),
+    unnest(sqlc.arg(labels)::varchar[]),
+    unnest(sqlc.arg(destinations)::text[]),
+    unnest(sqlc.arg(weights)::int[]);
+
+-- name: DeleteVariantsByURLID :exec
+DELETE FROM url_variants
+WHERE url_id = $1;
+
+-- name: ListVariantsByURLID :many
+SELECT * FROM url_variants
+WHERE url_id = $1
+ORDER BY id;
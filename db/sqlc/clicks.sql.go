@@ -24,9 +24,42 @@ func (q *Queries) CountClicksByURLID(ctx context.Context, urlID pgtype.Int8) (in
 	return click_count, err
 }
 
+const countClicksByVariant = `-- name: CountClicksByVariant :many
+SELECT variant, COUNT(*) AS click_count
+FROM clicks
+WHERE url_id = $1 AND variant IS NOT NULL
+GROUP BY variant
+ORDER BY variant
+`
+
+type CountClicksByVariantRow struct {
+	Variant    pgtype.Text `json:"variant"`
+	ClickCount int64       `json:"clickCount"`
+}
+
+func (q *Queries) CountClicksByVariant(ctx context.Context, urlID pgtype.Int8) ([]CountClicksByVariantRow, error) {
+	rows, err := q.db.Query(ctx, countClicksByVariant, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountClicksByVariantRow{}
+	for rows.Next() {
+		var i CountClicksByVariantRow
+		if err := rows.Scan(&i.Variant, &i.ClickCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getClicksByURLID = `-- name: GetClicksByURLID :many
 
-SELECT id, url_id, clicked_at, ip_address, user_agent, referer, device_type, country FROM clicks
+SELECT id, url_id, clicked_at, ip_address, user_agent, referer, device_type, country, source, variant FROM clicks
 WHERE url_id = $1
 ORDER BY clicked_at DESC
 LIMIT $2 OFFSET $3
@@ -56,6 +89,8 @@ func (q *Queries) GetClicksByURLID(ctx context.Context, arg GetClicksByURLIDPara
 			&i.Referer,
 			&i.DeviceType,
 			&i.Country,
+			&i.Source,
+			&i.Variant,
 		); err != nil {
 			return nil, err
 		}
@@ -68,8 +103,8 @@ func (q *Queries) GetClicksByURLID(ctx context.Context, arg GetClicksByURLIDPara
 }
 
 const insertClick = `-- name: InsertClick :one
-INSERT INTO clicks (url_id, ip_address, clicked_at, user_agent, referer, device_type, country)
-VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, url_id, clicked_at, ip_address, user_agent, referer, device_type, country
+INSERT INTO clicks (url_id, ip_address, clicked_at, user_agent, referer, device_type, country, source, variant)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, url_id, clicked_at, ip_address, user_agent, referer, device_type, country, source, variant
 `
 
 type InsertClickParams struct {
@@ -80,6 +115,8 @@ type InsertClickParams struct {
 	Referer    pgtype.Text      `json:"referer"`
 	DeviceType pgtype.Text      `json:"deviceType"`
 	Country    pgtype.Text      `json:"country"`
+	Source     pgtype.Text      `json:"source"`
+	Variant    pgtype.Text      `json:"variant"`
 }
 
 func (q *Queries) InsertClick(ctx context.Context, arg InsertClickParams) (Click, error) {
@@ -91,6 +128,8 @@ func (q *Queries) InsertClick(ctx context.Context, arg InsertClickParams) (Click
 		arg.Referer,
 		arg.DeviceType,
 		arg.Country,
+		arg.Source,
+		arg.Variant,
 	)
 	var i Click
 	err := row.Scan(
@@ -102,6 +141,8 @@ func (q *Queries) InsertClick(ctx context.Context, arg InsertClickParams) (Click
 		&i.Referer,
 		&i.DeviceType,
 		&i.Country,
+		&i.Source,
+		&i.Variant,
 	)
 	return i, err
 }
@@ -8,6 +8,23 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AbuseReport struct {
+	ID         int64            `json:"id"`
+	UrlID      int64            `json:"urlId"`
+	Reason     string           `json:"reason"`
+	Details    pgtype.Text      `json:"details"`
+	ReporterIp string           `json:"reporterIp"`
+	Status     string           `json:"status"`
+	CreatedAt  pgtype.Timestamp `json:"createdAt"`
+	ResolvedAt pgtype.Timestamp `json:"resolvedAt"`
+}
+
+type BannedDomain struct {
+	Domain    string           `json:"domain"`
+	Reason    pgtype.Text      `json:"reason"`
+	CreatedAt pgtype.Timestamp `json:"createdAt"`
+}
+
 type Click struct {
 	ID         int64            `json:"id"`
 	UrlID      pgtype.Int8      `json:"urlId"`
@@ -17,15 +34,70 @@ type Click struct {
 	Referer    pgtype.Text      `json:"referer"`
 	DeviceType pgtype.Text      `json:"deviceType"`
 	Country    pgtype.Text      `json:"country"`
+	Source     pgtype.Text      `json:"source"`
+	Variant    pgtype.Text      `json:"variant"`
+}
+
+type CodeLength struct {
+	Generator string           `json:"generator"`
+	Length    int32            `json:"length"`
+	UpdatedAt pgtype.Timestamp `json:"updatedAt"`
 }
 
 type Url struct {
+	ID                 int64            `json:"id"`
+	ShortCode          string           `json:"shortCode"`
+	OriginalUrl        string           `json:"originalUrl"`
+	CreatedAt          pgtype.Timestamp `json:"createdAt"`
+	UpdatedAt          pgtype.Timestamp `json:"updatedAt"`
+	ExpiresAt          pgtype.Timestamp `json:"expiresAt"`
+	ClickCount         pgtype.Int8      `json:"clickCount"`
+	IsActive           pgtype.Bool      `json:"isActive"`
+	CanonicalUrl       string           `json:"canonicalUrl"`
+	PasswordHash       pgtype.Text      `json:"passwordHash"`
+	MaxClicks          pgtype.Int8      `json:"maxClicks"`
+	ActiveFrom         pgtype.Timestamp `json:"activeFrom"`
+	AbuseStatus        pgtype.Text      `json:"abuseStatus"`
+	InterstitialReason pgtype.Text      `json:"interstitialReason"`
+	RedirectType       pgtype.Text      `json:"redirectType"`
+	IosUrl             pgtype.Text      `json:"iosUrl"`
+	AndroidUrl         pgtype.Text      `json:"androidUrl"`
+	DesktopUrl         pgtype.Text      `json:"desktopUrl"`
+	AppUrl             pgtype.Text      `json:"appUrl"`
+	GeoTargeted        bool             `json:"geoTargeted"`
+	VariantMode        pgtype.Text      `json:"variantMode"`
+	Timezone           pgtype.Text      `json:"timezone"`
+	ScheduleRouted     bool             `json:"scheduleRouted"`
+	ForwardQuery       pgtype.Text      `json:"forwardQuery"`
+	ForwardPath        bool             `json:"forwardPath"`
+}
+
+type UrlGeoRule struct {
+	ID          int64            `json:"id"`
+	UrlID       int64            `json:"urlId"`
+	CountryCode string           `json:"countryCode"`
+	Destination string           `json:"destination"`
+	CreatedAt   pgtype.Timestamp `json:"createdAt"`
+}
+
+type UrlScheduleRule struct {
+	ID          int64            `json:"id"`
+	UrlID       int64            `json:"urlId"`
+	Position    int32            `json:"position"`
+	Days        int16            `json:"days"`
+	StartMinute pgtype.Int2      `json:"startMinute"`
+	EndMinute   pgtype.Int2      `json:"endMinute"`
+	StartDate   pgtype.Date      `json:"startDate"`
+	EndDate     pgtype.Date      `json:"endDate"`
+	Destination string           `json:"destination"`
+	CreatedAt   pgtype.Timestamp `json:"createdAt"`
+}
+
+type UrlVariant struct {
 	ID          int64            `json:"id"`
-	ShortCode   string           `json:"shortCode"`
-	OriginalUrl string           `json:"originalUrl"`
+	UrlID       int64            `json:"urlId"`
+	Label       string           `json:"label"`
+	Destination string           `json:"destination"`
+	Weight      int32            `json:"weight"`
 	CreatedAt   pgtype.Timestamp `json:"createdAt"`
-	UpdatedAt   pgtype.Timestamp `json:"updatedAt"`
-	ExpiresAt   pgtype.Timestamp `json:"expiresAt"`
-	ClickCount  pgtype.Int8      `json:"clickCount"`
-	IsActive    pgtype.Bool      `json:"isActive"`
 }
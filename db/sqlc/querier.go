@@ -11,22 +11,74 @@ import (
 )
 
 type Querier interface {
+	BanURL(ctx context.Context, id int64) (int64, error)
+	BanURLsByDomain(ctx context.Context, domain string) (int64, error)
 	CheckShortCodeExists(ctx context.Context, shortCode string) (bool, error)
 	CountAllClicks(ctx context.Context) (int64, error)
 	CountClicksByURLID(ctx context.Context, urlID pgtype.Int8) (int64, error)
+	CountClicksByVariant(ctx context.Context, urlID pgtype.Int8) ([]CountClicksByVariantRow, error)
 	CountClicksToday(ctx context.Context) (int64, error)
-	CountURLs(ctx context.Context) (int64, error)
+	CountExpiredURLs(ctx context.Context) (int64, error)
+	CountOpenAbuseReports(ctx context.Context, urlID int64) (int64, error)
+	CountReportedURLs(ctx context.Context) (int64, error)
+	CountURLs(ctx context.Context, state pgtype.Text) (int64, error)
 	CountURLsToday(ctx context.Context) (int64, error)
+	// db/queries/abuse.sql
+	CreateAbuseReport(ctx context.Context, arg CreateAbuseReportParams) (AbuseReport, error)
+	CreateBannedDomain(ctx context.Context, arg CreateBannedDomainParams) (BannedDomain, error)
+	// db/queries/geo_rules.sql
+	CreateGeoRules(ctx context.Context, arg CreateGeoRulesParams) error
+	// db/queries/schedule_rules.sql
+	CreateScheduleRule(ctx context.Context, arg CreateScheduleRuleParams) error
+	CreateShortCodeFoldIndex(ctx context.Context) error
 	// db/queries/urls.sql
 	CreateURL(ctx context.Context, arg CreateURLParams) (Url, error)
+	CreateURLsBatch(ctx context.Context, arg CreateURLsBatchParams) ([]string, error)
+	// db/queries/variants.sql
+	CreateVariants(ctx context.Context, arg CreateVariantsParams) error
+	DeactivateExpiredURLs(ctx context.Context, limit int32) ([]string, error)
 	DeactivateURL(ctx context.Context, shortCode string) error
+	DeleteBannedDomain(ctx context.Context, domain string) (int64, error)
+	DeleteGeoRulesByURLID(ctx context.Context, urlID int64) error
+	DeleteInactiveURLs(ctx context.Context, arg DeleteInactiveURLsParams) (int64, error)
+	DeleteScheduleRulesByURLID(ctx context.Context, urlID int64) error
+	DeleteVariantsByURLID(ctx context.Context, urlID int64) error
+	FlagURLInterstitial(ctx context.Context, arg FlagURLInterstitialParams) (int64, error)
+	GetActiveURLByCanonicalURL(ctx context.Context, canonicalUrl string) (Url, error)
 	GetClicksByURLID(ctx context.Context, arg GetClicksByURLIDParams) ([]Click, error)
+	// db/queries/code_lengths.sql
+	GetCodeLength(ctx context.Context, generator string) (int32, error)
+	GetGeoRuleDestination(ctx context.Context, arg GetGeoRuleDestinationParams) (string, error)
 	GetTopURLs(ctx context.Context, limit int32) ([]GetTopURLsRow, error)
 	GetURLByShortCode(ctx context.Context, shortCode string) (Url, error)
+	GetURLByShortCodeFolded(ctx context.Context, lower string) (Url, error)
 	GetURLStats(ctx context.Context, shortCode string) (GetURLStatsRow, error)
 	IncrementClickCount(ctx context.Context, shortCode string) error
+	IncrementClickCountWithinLimit(ctx context.Context, shortCode string) (int64, error)
 	InsertClick(ctx context.Context, arg InsertClickParams) (Click, error)
+	ListAbuseReportsByURLID(ctx context.Context, urlID int64) ([]AbuseReport, error)
+	ListActiveURLsAfterID(ctx context.Context, arg ListActiveURLsAfterIDParams) ([]ListActiveURLsAfterIDRow, error)
+	ListActiveURLsByCanonicalURLs(ctx context.Context, canonicalUrls []string) ([]ListActiveURLsByCanonicalURLsRow, error)
+	ListBannedDomains(ctx context.Context) ([]BannedDomain, error)
+	ListGeoRulesByURLID(ctx context.Context, urlID int64) ([]UrlGeoRule, error)
+	ListReportedURLs(ctx context.Context, arg ListReportedURLsParams) ([]ListReportedURLsRow, error)
+	ListScheduleRulesByURLID(ctx context.Context, urlID int64) ([]UrlScheduleRule, error)
+	ListShortCodeCaseConflicts(ctx context.Context) ([]ListShortCodeCaseConflictsRow, error)
 	ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error)
+	ListUncanonicalizedURLsAfterID(ctx context.Context, arg ListUncanonicalizedURLsAfterIDParams) ([]ListUncanonicalizedURLsAfterIDRow, error)
+	ListVariantsByURLID(ctx context.Context, urlID int64) ([]UrlVariant, error)
+	NextShortCodeIDs(ctx context.Context, count int32) ([]int64, error)
+	QuarantineURL(ctx context.Context, id int64) (int64, error)
+	RaiseCodeLength(ctx context.Context, arg RaiseCodeLengthParams) error
+	ReinstateURL(ctx context.Context, id int64) (int64, error)
+	ResolveAbuseReports(ctx context.Context, arg ResolveAbuseReportsParams) (int64, error)
+	SetURLCanonical(ctx context.Context, arg SetURLCanonicalParams) error
+	SetURLGeoTargeted(ctx context.Context, arg SetURLGeoTargetedParams) error
+	SetURLInterstitial(ctx context.Context, arg SetURLInterstitialParams) (int64, error)
+	SetURLSchedule(ctx context.Context, arg SetURLScheduleParams) error
+	SetURLVariantMode(ctx context.Context, arg SetURLVariantModeParams) error
+	SuspendURL(ctx context.Context, id int64) (int64, error)
+	TryAdvisoryXactLock(ctx context.Context, lockID int64) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
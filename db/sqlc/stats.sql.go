@@ -50,16 +50,19 @@ const getTopURLs = `-- name: GetTopURLs :many
 SELECT 
     u.short_code,
     u.original_url,
-    u.click_count
+    u.click_count,
+    u.expires_at
 FROM urls u
+WHERE u.password_hash IS NULL AND u.is_active = true AND u.abuse_status IS NULL
 ORDER BY u.click_count DESC
 LIMIT $1
 `
 
 type GetTopURLsRow struct {
-	ShortCode   string      `json:"shortCode"`
-	OriginalUrl string      `json:"originalUrl"`
-	ClickCount  pgtype.Int8 `json:"clickCount"`
+	ShortCode   string           `json:"shortCode"`
+	OriginalUrl string           `json:"originalUrl"`
+	ClickCount  pgtype.Int8      `json:"clickCount"`
+	ExpiresAt   pgtype.Timestamp `json:"expiresAt"`
 }
 
 func (q *Queries) GetTopURLs(ctx context.Context, limit int32) ([]GetTopURLsRow, error) {
@@ -71,7 +74,12 @@ func (q *Queries) GetTopURLs(ctx context.Context, limit int32) ([]GetTopURLsRow,
 	items := []GetTopURLsRow{}
 	for rows.Next() {
 		var i GetTopURLsRow
-		if err := rows.Scan(&i.ShortCode, &i.OriginalUrl, &i.ClickCount); err != nil {
+		if err := rows.Scan(
+			&i.ShortCode,
+			&i.OriginalUrl,
+			&i.ClickCount,
+			&i.ExpiresAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -2,13 +2,22 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Store interface {
 	Querier
+	SweepURLsTx(ctx context.Context, arg SweepURLsTxParams) (SweepURLsTxResult, error)
+	ReportURLTx(ctx context.Context, arg ReportURLTxParams) (ReportURLTxResult, error)
+	SetGeoRulesTx(ctx context.Context, arg SetGeoRulesTxParams) error
+	SetVariantsTx(ctx context.Context, arg SetVariantsTxParams) error
+	SetScheduleRulesTx(ctx context.Context, arg SetScheduleRulesTxParams) error
+	CreateURLWithRulesTx(ctx context.Context, arg CreateURLWithRulesTxParams) (Url, error)
 }
 
 type SQLStore struct {
@@ -33,3 +42,312 @@ func NewStore(dbSource string) (Store, error) {
 func (store *SQLStore) GetDB() *pgxpool.Pool {
 	return store.db
 }
+
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+type SweepURLsTxParams struct {
+	LockID    int64
+	BatchSize int32
+	// Retention is how long a link stays inactive before it is hard-deleted.
+	// Zero disables deletion.
+	Retention pgtype.Interval
+}
+
+type SweepURLsTxResult struct {
+	// Locked is false when another instance holds the sweep lock and this
+	// run did nothing.
+	Locked      bool
+	Deactivated []string
+	Deleted     int64
+}
+
+// SweepURLsTx deactivates expired links and purges long-inactive ones in a
+// single transaction guarded by a transaction-level advisory lock, so only
+// one instance sweeps at a time when several share the database.
+func (store *SQLStore) SweepURLsTx(ctx context.Context, arg SweepURLsTxParams) (SweepURLsTxResult, error) {
+	var result SweepURLsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Locked, err = q.TryAdvisoryXactLock(ctx, arg.LockID)
+		if err != nil || !result.Locked {
+			return err
+		}
+
+		result.Deactivated, err = q.DeactivateExpiredURLs(ctx, arg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		if arg.Retention.Valid {
+			result.Deleted, err = q.DeleteInactiveURLs(ctx, DeleteInactiveURLsParams{
+				Retention: arg.Retention,
+				BatchSize: arg.BatchSize,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+type ReportURLTxParams struct {
+	CreateAbuseReportParams
+	// Threshold is the number of open reports that suspends the link. Zero
+	// disables automatic suspension.
+	Threshold int64
+	// InterstitialThreshold is the number of open reports after which
+	// visitors see a warning page first. Zero disables it.
+	InterstitialThreshold int64
+}
+
+type ReportURLTxResult struct {
+	// Duplicate is true when the reporter already has an open report for
+	// this link and nothing was stored.
+	Duplicate   bool
+	ReportCount int64
+	Flagged     bool
+	Suspended   bool
+}
+
+// ReportURLTx stores an abuse report, puts the link behind the warning
+// interstitial and then suspends it as its open reports reach the thresholds,
+// in one transaction so concurrent reports cannot both miss them.
+func (store *SQLStore) ReportURLTx(ctx context.Context, arg ReportURLTxParams) (ReportURLTxResult, error) {
+	var result ReportURLTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.CreateAbuseReport(ctx, arg.CreateAbuseReportParams)
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Duplicate = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result.ReportCount, err = q.CountOpenAbuseReports(ctx, arg.UrlID)
+		if err != nil {
+			return err
+		}
+
+		if arg.InterstitialThreshold > 0 && result.ReportCount >= arg.InterstitialThreshold {
+			flagged, err := q.FlagURLInterstitial(ctx, FlagURLInterstitialParams{
+				ID:                 arg.UrlID,
+				InterstitialReason: pgtype.Text{String: "reports", Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			result.Flagged = flagged > 0
+		}
+
+		if arg.Threshold > 0 && result.ReportCount >= arg.Threshold {
+			suspended, err := q.SuspendURL(ctx, arg.UrlID)
+			if err != nil {
+				return err
+			}
+			result.Suspended = suspended > 0
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+type SetGeoRulesTxParams struct {
+	UrlID int64
+	// Rules maps upper-case ISO country codes to destinations. An empty map
+	// removes every rule.
+	Rules map[string]string
+}
+
+// SetGeoRulesTx replaces all geo rules of a link and keeps urls.geo_targeted
+// in step with them.
+func (store *SQLStore) SetGeoRulesTx(ctx context.Context, arg SetGeoRulesTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return setGeoRules(ctx, q, arg)
+	})
+}
+
+func setGeoRules(ctx context.Context, q *Queries, arg SetGeoRulesTxParams) error {
+	if err := q.DeleteGeoRulesByURLID(ctx, arg.UrlID); err != nil {
+		return err
+	}
+
+	if len(arg.Rules) > 0 {
+		params := CreateGeoRulesParams{UrlID: arg.UrlID}
+		for country, destination := range arg.Rules {
+			params.CountryCodes = append(params.CountryCodes, country)
+			params.Destinations = append(params.Destinations, destination)
+		}
+		if err := q.CreateGeoRules(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	return q.SetURLGeoTargeted(ctx, SetURLGeoTargetedParams{
+		ID:          arg.UrlID,
+		GeoTargeted: len(arg.Rules) > 0,
+	})
+}
+
+type SetVariantsTxParams struct {
+	UrlID int64
+	// Mode is "random" or "sticky". It is ignored, and the column cleared,
+	// when Variants is empty.
+	Mode     string
+	Variants []UrlVariant
+}
+
+// SetVariantsTx replaces all split-test variants of a link and keeps
+// urls.variant_mode in step with them.
+func (store *SQLStore) SetVariantsTx(ctx context.Context, arg SetVariantsTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return setVariants(ctx, q, arg)
+	})
+}
+
+func setVariants(ctx context.Context, q *Queries, arg SetVariantsTxParams) error {
+	if err := q.DeleteVariantsByURLID(ctx, arg.UrlID); err != nil {
+		return err
+	}
+
+	mode := pgtype.Text{}
+	if len(arg.Variants) > 0 {
+		params := CreateVariantsParams{UrlID: arg.UrlID}
+		for _, variant := range arg.Variants {
+			params.Labels = append(params.Labels, variant.Label)
+			params.Destinations = append(params.Destinations, variant.Destination)
+			params.Weights = append(params.Weights, variant.Weight)
+		}
+		if err := q.CreateVariants(ctx, params); err != nil {
+			return err
+		}
+		mode = pgtype.Text{String: arg.Mode, Valid: true}
+	}
+
+	return q.SetURLVariantMode(ctx, SetURLVariantModeParams{
+		ID:          arg.UrlID,
+		VariantMode: mode,
+	})
+}
+
+type SetScheduleRulesTxParams struct {
+	UrlID int64
+	// Timezone is the IANA name the rules are evaluated in. It is cleared
+	// with the rules when Rules is empty.
+	Timezone string
+	// Rules are stored in order; Position is assigned from the slice index.
+	Rules []UrlScheduleRule
+}
+
+// SetScheduleRulesTx replaces all schedule rules of a link and keeps
+// urls.schedule_routed and urls.timezone in step with them.
+func (store *SQLStore) SetScheduleRulesTx(ctx context.Context, arg SetScheduleRulesTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return setScheduleRules(ctx, q, arg)
+	})
+}
+
+func setScheduleRules(ctx context.Context, q *Queries, arg SetScheduleRulesTxParams) error {
+	if err := q.DeleteScheduleRulesByURLID(ctx, arg.UrlID); err != nil {
+		return err
+	}
+
+	for i, rule := range arg.Rules {
+		err := q.CreateScheduleRule(ctx, CreateScheduleRuleParams{
+			UrlID:       arg.UrlID,
+			Position:    int32(i),
+			Days:        rule.Days,
+			StartMinute: rule.StartMinute,
+			EndMinute:   rule.EndMinute,
+			StartDate:   rule.StartDate,
+			EndDate:     rule.EndDate,
+			Destination: rule.Destination,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return q.SetURLSchedule(ctx, SetURLScheduleParams{
+		ID:             arg.UrlID,
+		ScheduleRouted: len(arg.Rules) > 0,
+		Timezone:       pgtype.Text{String: arg.Timezone, Valid: len(arg.Rules) > 0},
+	})
+}
+
+type CreateURLWithRulesTxParams struct {
+	CreateURLParams
+	// GeoRules, Variants and ScheduleRules are optional; at most one kind is
+	// set. VariantMode and Timezone are ignored without their rules.
+	GeoRules      map[string]string
+	VariantMode   string
+	Variants      []UrlVariant
+	Timezone      string
+	ScheduleRules []UrlScheduleRule
+}
+
+// CreateURLWithRulesTx creates a link together with its routing rules, so the
+// link is never visible, nor its code taken, without them.
+func (store *SQLStore) CreateURLWithRulesTx(ctx context.Context, arg CreateURLWithRulesTxParams) (Url, error) {
+	var result Url
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.CreateURL(ctx, arg.CreateURLParams)
+		if err != nil {
+			return err
+		}
+
+		if len(arg.GeoRules) > 0 {
+			if err := setGeoRules(ctx, q, SetGeoRulesTxParams{UrlID: result.ID, Rules: arg.GeoRules}); err != nil {
+				return err
+			}
+			result.GeoTargeted = true
+		}
+
+		if len(arg.Variants) > 0 {
+			if err := setVariants(ctx, q, SetVariantsTxParams{UrlID: result.ID, Mode: arg.VariantMode, Variants: arg.Variants}); err != nil {
+				return err
+			}
+			result.VariantMode = pgtype.Text{String: arg.VariantMode, Valid: true}
+		}
+
+		if len(arg.ScheduleRules) > 0 {
+			if err := setScheduleRules(ctx, q, SetScheduleRulesTxParams{UrlID: result.ID, Timezone: arg.Timezone, Rules: arg.ScheduleRules}); err != nil {
+				return err
+			}
+			result.ScheduleRouted = true
+			result.Timezone = pgtype.Text{String: arg.Timezone, Valid: true}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -11,6 +11,45 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const banURL = `-- name: BanURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'banned', updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) BanURL(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, banURL, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const banURLsByDomain = `-- name: BanURLsByDomain :execrows
+UPDATE urls u
+SET is_active = false, abuse_status = 'banned', updated_at = NOW()
+WHERE u.abuse_status IS DISTINCT FROM 'banned'
+  AND EXISTS (
+    SELECT 1
+    FROM unnest(
+        ARRAY[u.original_url, u.ios_url, u.android_url, u.desktop_url]
+        || ARRAY(SELECT g.destination FROM url_geo_rules g WHERE g.url_id = u.id)
+        || ARRAY(SELECT v.destination FROM url_variants v WHERE v.url_id = u.id)
+        || ARRAY(SELECT r.destination FROM url_schedule_rules r WHERE r.url_id = u.id)
+    ) AS d(destination),
+    LATERAL (SELECT lower(substring(d.destination FROM '^[a-zA-Z]+://(?:[^@/]*@)?([^/:?#]+)')) AS host) h
+    WHERE h.host = $1::text OR h.host LIKE '%.' || $1::text
+  )
+`
+
+func (q *Queries) BanURLsByDomain(ctx context.Context, domain string) (int64, error) {
+	result, err := q.db.Exec(ctx, banURLsByDomain, domain)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const checkShortCodeExists = `-- name: CheckShortCodeExists :one
 SELECT EXISTS(
     SELECT 1 FROM urls WHERE short_code = $1
@@ -24,34 +63,94 @@ func (q *Queries) CheckShortCodeExists(ctx context.Context, shortCode string) (b
 	return exists, err
 }
 
+const countExpiredURLs = `-- name: CountExpiredURLs :one
+SELECT COUNT(*) AS url_count
+FROM urls
+WHERE expires_at IS NOT NULL AND expires_at <= (NOW() AT TIME ZONE 'UTC')
+`
+
+func (q *Queries) CountExpiredURLs(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countExpiredURLs)
+	var url_count int64
+	err := row.Scan(&url_count)
+	return url_count, err
+}
+
 const countURLs = `-- name: CountURLs :one
 SELECT COUNT(*) AS url_count
 FROM urls
-WHERE is_active = true
+WHERE (is_active = true OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+  AND (
+    $1::text IS NULL
+    OR ($1 = 'scheduled' AND active_from > (NOW() AT TIME ZONE 'UTC'))
+    OR ($1 = 'expired' AND expires_at <= (NOW() AT TIME ZONE 'UTC'))
+    OR ($1 = 'live'
+        AND (active_from IS NULL OR active_from <= (NOW() AT TIME ZONE 'UTC'))
+        AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC')))
+  )
 `
 
-func (q *Queries) CountURLs(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countURLs)
+func (q *Queries) CountURLs(ctx context.Context, state pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countURLs, state)
 	var url_count int64
 	err := row.Scan(&url_count)
 	return url_count, err
 }
 
+const createShortCodeFoldIndex = `-- name: CreateShortCodeFoldIndex :exec
+CREATE UNIQUE INDEX IF NOT EXISTS urls_short_code_lower_key ON urls (lower(short_code))
+`
+
+func (q *Queries) CreateShortCodeFoldIndex(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, createShortCodeFoldIndex)
+	return err
+}
+
 const createURL = `-- name: CreateURL :one
 
-INSERT INTO urls (short_code, original_url)
-VALUES ($1, $2)
-RETURNING id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active
+INSERT INTO urls (short_code, original_url, expires_at, canonical_url, password_hash, max_clicks, active_from, interstitial_reason, redirect_type,
+    ios_url, android_url, desktop_url, app_url, forward_query, forward_path)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active, canonical_url, password_hash, max_clicks, active_from, abuse_status, interstitial_reason, redirect_type, ios_url, android_url, desktop_url, app_url, geo_targeted, variant_mode, timezone, schedule_routed, forward_query, forward_path
 `
 
 type CreateURLParams struct {
-	ShortCode   string `json:"shortCode"`
-	OriginalUrl string `json:"originalUrl"`
+	ShortCode          string           `json:"shortCode"`
+	OriginalUrl        string           `json:"originalUrl"`
+	ExpiresAt          pgtype.Timestamp `json:"expiresAt"`
+	CanonicalUrl       string           `json:"canonicalUrl"`
+	PasswordHash       pgtype.Text      `json:"passwordHash"`
+	MaxClicks          pgtype.Int8      `json:"maxClicks"`
+	ActiveFrom         pgtype.Timestamp `json:"activeFrom"`
+	InterstitialReason pgtype.Text      `json:"interstitialReason"`
+	RedirectType       pgtype.Text      `json:"redirectType"`
+	IosUrl             pgtype.Text      `json:"iosUrl"`
+	AndroidUrl         pgtype.Text      `json:"androidUrl"`
+	DesktopUrl         pgtype.Text      `json:"desktopUrl"`
+	AppUrl             pgtype.Text      `json:"appUrl"`
+	ForwardQuery       pgtype.Text      `json:"forwardQuery"`
+	ForwardPath        bool             `json:"forwardPath"`
 }
 
 // db/queries/urls.sql
 func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, error) {
-	row := q.db.QueryRow(ctx, createURL, arg.ShortCode, arg.OriginalUrl)
+	row := q.db.QueryRow(ctx, createURL,
+		arg.ShortCode,
+		arg.OriginalUrl,
+		arg.ExpiresAt,
+		arg.CanonicalUrl,
+		arg.PasswordHash,
+		arg.MaxClicks,
+		arg.ActiveFrom,
+		arg.InterstitialReason,
+		arg.RedirectType,
+		arg.IosUrl,
+		arg.AndroidUrl,
+		arg.DesktopUrl,
+		arg.AppUrl,
+		arg.ForwardQuery,
+		arg.ForwardPath,
+	)
 	var i Url
 	err := row.Scan(
 		&i.ID,
@@ -62,13 +161,124 @@ func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, erro
 		&i.ExpiresAt,
 		&i.ClickCount,
 		&i.IsActive,
+		&i.CanonicalUrl,
+		&i.PasswordHash,
+		&i.MaxClicks,
+		&i.ActiveFrom,
+		&i.AbuseStatus,
+		&i.InterstitialReason,
+		&i.RedirectType,
+		&i.IosUrl,
+		&i.AndroidUrl,
+		&i.DesktopUrl,
+		&i.AppUrl,
+		&i.GeoTargeted,
+		&i.VariantMode,
+		&i.Timezone,
+		&i.ScheduleRouted,
+		&i.ForwardQuery,
+		&i.ForwardPath,
 	)
 	return i, err
 }
 
+const createURLsBatch = `-- name: CreateURLsBatch :many
+INSERT INTO urls (short_code, original_url, expires_at, canonical_url, max_clicks, active_from, interstitial_reason, redirect_type,
+    forward_query, forward_path)
+SELECT
+    unnest($1::varchar[]),
+    unnest($2::text[]),
+    unnest($3::timestamp[]),
+    unnest($4::text[]),
+    unnest($5::bigint[]),
+    unnest($6::timestamp[]),
+    unnest($7::varchar[]),
+    unnest($8::varchar[]),
+    unnest($9::varchar[]),
+    unnest($10::boolean[])
+ON CONFLICT DO NOTHING
+RETURNING short_code
+`
+
+type CreateURLsBatchParams struct {
+	ShortCodes          []string           `json:"shortCodes"`
+	OriginalUrls        []string           `json:"originalUrls"`
+	ExpiresAts          []pgtype.Timestamp `json:"expiresAts"`
+	CanonicalUrls       []string           `json:"canonicalUrls"`
+	MaxClicks           []pgtype.Int8      `json:"maxClicks"`
+	ActiveFroms         []pgtype.Timestamp `json:"activeFroms"`
+	InterstitialReasons []pgtype.Text      `json:"interstitialReasons"`
+	RedirectTypes       []pgtype.Text      `json:"redirectTypes"`
+	ForwardQueries      []pgtype.Text      `json:"forwardQueries"`
+	ForwardPaths        []bool             `json:"forwardPaths"`
+}
+
+func (q *Queries) CreateURLsBatch(ctx context.Context, arg CreateURLsBatchParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, createURLsBatch,
+		arg.ShortCodes,
+		arg.OriginalUrls,
+		arg.ExpiresAts,
+		arg.CanonicalUrls,
+		arg.MaxClicks,
+		arg.ActiveFroms,
+		arg.InterstitialReasons,
+		arg.RedirectTypes,
+		arg.ForwardQueries,
+		arg.ForwardPaths,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var short_code string
+		if err := rows.Scan(&short_code); err != nil {
+			return nil, err
+		}
+		items = append(items, short_code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deactivateExpiredURLs = `-- name: DeactivateExpiredURLs :many
+UPDATE urls
+SET is_active = false, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM urls
+    WHERE is_active = true AND expires_at IS NOT NULL AND expires_at <= (NOW() AT TIME ZONE 'UTC')
+    ORDER BY expires_at
+    LIMIT $1
+)
+RETURNING short_code
+`
+
+func (q *Queries) DeactivateExpiredURLs(ctx context.Context, limit int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, deactivateExpiredURLs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var short_code string
+		if err := rows.Scan(&short_code); err != nil {
+			return nil, err
+		}
+		items = append(items, short_code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deactivateURL = `-- name: DeactivateURL :exec
 UPDATE urls
-SET is_active = false
+SET is_active = false, updated_at = NOW()
 WHERE short_code = $1
 `
 
@@ -77,9 +287,96 @@ func (q *Queries) DeactivateURL(ctx context.Context, shortCode string) error {
 	return err
 }
 
+const deleteInactiveURLs = `-- name: DeleteInactiveURLs :execrows
+DELETE FROM urls
+WHERE id IN (
+    SELECT id FROM urls
+    WHERE is_active = false AND abuse_status IS NULL AND updated_at < NOW() - $1::interval
+    LIMIT $2::int
+)
+`
+
+type DeleteInactiveURLsParams struct {
+	Retention pgtype.Interval `json:"retention"`
+	BatchSize int32           `json:"batchSize"`
+}
+
+func (q *Queries) DeleteInactiveURLs(ctx context.Context, arg DeleteInactiveURLsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteInactiveURLs, arg.Retention, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const flagURLInterstitial = `-- name: FlagURLInterstitial :execrows
+UPDATE urls
+SET interstitial_reason = $2, updated_at = NOW()
+WHERE id = $1 AND interstitial_reason IS NULL
+`
+
+type FlagURLInterstitialParams struct {
+	ID                 int64       `json:"id"`
+	InterstitialReason pgtype.Text `json:"interstitialReason"`
+}
+
+func (q *Queries) FlagURLInterstitial(ctx context.Context, arg FlagURLInterstitialParams) (int64, error) {
+	result, err := q.db.Exec(ctx, flagURLInterstitial, arg.ID, arg.InterstitialReason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getActiveURLByCanonicalURL = `-- name: GetActiveURLByCanonicalURL :one
+SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active, canonical_url, password_hash, max_clicks, active_from, abuse_status, interstitial_reason, redirect_type, ios_url, android_url, desktop_url, app_url, geo_targeted, variant_mode, timezone, schedule_routed, forward_query, forward_path FROM urls
+WHERE canonical_url = $1 AND is_active = true
+  AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+  AND password_hash IS NULL AND max_clicks IS NULL AND active_from IS NULL
+  AND interstitial_reason IS NULL AND redirect_type IS NULL
+  AND ios_url IS NULL AND android_url IS NULL AND desktop_url IS NULL AND app_url IS NULL
+  AND geo_targeted = false AND variant_mode IS NULL AND schedule_routed = false
+  AND forward_query IS NULL AND forward_path = false
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetActiveURLByCanonicalURL(ctx context.Context, canonicalUrl string) (Url, error) {
+	row := q.db.QueryRow(ctx, getActiveURLByCanonicalURL, canonicalUrl)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.ClickCount,
+		&i.IsActive,
+		&i.CanonicalUrl,
+		&i.PasswordHash,
+		&i.MaxClicks,
+		&i.ActiveFrom,
+		&i.AbuseStatus,
+		&i.InterstitialReason,
+		&i.RedirectType,
+		&i.IosUrl,
+		&i.AndroidUrl,
+		&i.DesktopUrl,
+		&i.AppUrl,
+		&i.GeoTargeted,
+		&i.VariantMode,
+		&i.Timezone,
+		&i.ScheduleRouted,
+		&i.ForwardQuery,
+		&i.ForwardPath,
+	)
+	return i, err
+}
+
 const getURLByShortCode = `-- name: GetURLByShortCode :one
-SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active FROM urls
-WHERE short_code = $1 AND is_active = true
+SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active, canonical_url, password_hash, max_clicks, active_from, abuse_status, interstitial_reason, redirect_type, ios_url, android_url, desktop_url, app_url, geo_targeted, variant_mode, timezone, schedule_routed, forward_query, forward_path FROM urls
+WHERE short_code = $1 AND (is_active = true OR abuse_status IS NOT NULL OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
 LIMIT 1
 `
 
@@ -95,6 +392,62 @@ func (q *Queries) GetURLByShortCode(ctx context.Context, shortCode string) (Url,
 		&i.ExpiresAt,
 		&i.ClickCount,
 		&i.IsActive,
+		&i.CanonicalUrl,
+		&i.PasswordHash,
+		&i.MaxClicks,
+		&i.ActiveFrom,
+		&i.AbuseStatus,
+		&i.InterstitialReason,
+		&i.RedirectType,
+		&i.IosUrl,
+		&i.AndroidUrl,
+		&i.DesktopUrl,
+		&i.AppUrl,
+		&i.GeoTargeted,
+		&i.VariantMode,
+		&i.Timezone,
+		&i.ScheduleRouted,
+		&i.ForwardQuery,
+		&i.ForwardPath,
+	)
+	return i, err
+}
+
+const getURLByShortCodeFolded = `-- name: GetURLByShortCodeFolded :one
+SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active, canonical_url, password_hash, max_clicks, active_from, abuse_status, interstitial_reason, redirect_type, ios_url, android_url, desktop_url, app_url, geo_targeted, variant_mode, timezone, schedule_routed, forward_query, forward_path FROM urls
+WHERE lower(short_code) = lower($1) AND (is_active = true OR abuse_status IS NOT NULL OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+LIMIT 1
+`
+
+func (q *Queries) GetURLByShortCodeFolded(ctx context.Context, lower string) (Url, error) {
+	row := q.db.QueryRow(ctx, getURLByShortCodeFolded, lower)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortCode,
+		&i.OriginalUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.ClickCount,
+		&i.IsActive,
+		&i.CanonicalUrl,
+		&i.PasswordHash,
+		&i.MaxClicks,
+		&i.ActiveFrom,
+		&i.AbuseStatus,
+		&i.InterstitialReason,
+		&i.RedirectType,
+		&i.IosUrl,
+		&i.AndroidUrl,
+		&i.DesktopUrl,
+		&i.AppUrl,
+		&i.GeoTargeted,
+		&i.VariantMode,
+		&i.Timezone,
+		&i.ScheduleRouted,
+		&i.ForwardQuery,
+		&i.ForwardPath,
 	)
 	return i, err
 }
@@ -104,7 +457,10 @@ SELECT
     short_code,
     original_url,
     click_count,
-    created_at
+    created_at,
+    expires_at,
+    active_from,
+    password_hash IS NOT NULL AS has_password
 FROM urls
 WHERE short_code = $1 AND is_active = true
 LIMIT 1
@@ -115,6 +471,9 @@ type GetURLStatsRow struct {
 	OriginalUrl string           `json:"originalUrl"`
 	ClickCount  pgtype.Int8      `json:"clickCount"`
 	CreatedAt   pgtype.Timestamp `json:"createdAt"`
+	ExpiresAt   pgtype.Timestamp `json:"expiresAt"`
+	ActiveFrom  pgtype.Timestamp `json:"activeFrom"`
+	HasPassword bool             `json:"hasPassword"`
 }
 
 func (q *Queries) GetURLStats(ctx context.Context, shortCode string) (GetURLStatsRow, error) {
@@ -125,6 +484,9 @@ func (q *Queries) GetURLStats(ctx context.Context, shortCode string) (GetURLStat
 		&i.OriginalUrl,
 		&i.ClickCount,
 		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.ActiveFrom,
+		&i.HasPassword,
 	)
 	return i, err
 }
@@ -140,20 +502,165 @@ func (q *Queries) IncrementClickCount(ctx context.Context, shortCode string) err
 	return err
 }
 
+const incrementClickCountWithinLimit = `-- name: IncrementClickCountWithinLimit :execrows
+UPDATE urls
+SET click_count = click_count + 1
+WHERE short_code = $1 AND (max_clicks IS NULL OR click_count < max_clicks)
+`
+
+func (q *Queries) IncrementClickCountWithinLimit(ctx context.Context, shortCode string) (int64, error) {
+	result, err := q.db.Exec(ctx, incrementClickCountWithinLimit, shortCode)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listActiveURLsAfterID = `-- name: ListActiveURLsAfterID :many
+SELECT
+    u.id,
+    u.short_code,
+    array_remove(
+        ARRAY[u.original_url, u.ios_url, u.android_url, u.desktop_url]
+        || ARRAY(SELECT g.destination FROM url_geo_rules g WHERE g.url_id = u.id)
+        || ARRAY(SELECT v.destination FROM url_variants v WHERE v.url_id = u.id)
+        || ARRAY(SELECT r.destination FROM url_schedule_rules r WHERE r.url_id = u.id),
+        NULL
+    )::text[] AS destinations
+FROM urls u
+WHERE u.is_active = true AND u.id > $1
+ORDER BY u.id
+LIMIT $2
+`
+
+type ListActiveURLsAfterIDParams struct {
+	ID    int64 `json:"id"`
+	Limit int32 `json:"limit"`
+}
+
+type ListActiveURLsAfterIDRow struct {
+	ID           int64    `json:"id"`
+	ShortCode    string   `json:"shortCode"`
+	Destinations []string `json:"destinations"`
+}
+
+func (q *Queries) ListActiveURLsAfterID(ctx context.Context, arg ListActiveURLsAfterIDParams) ([]ListActiveURLsAfterIDRow, error) {
+	rows, err := q.db.Query(ctx, listActiveURLsAfterID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListActiveURLsAfterIDRow{}
+	for rows.Next() {
+		var i ListActiveURLsAfterIDRow
+		if err := rows.Scan(&i.ID, &i.ShortCode, &i.Destinations); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveURLsByCanonicalURLs = `-- name: ListActiveURLsByCanonicalURLs :many
+SELECT DISTINCT ON (canonical_url) short_code, canonical_url, expires_at
+FROM urls
+WHERE canonical_url = ANY($1::text[]) AND is_active = true
+  AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC'))
+  AND password_hash IS NULL AND max_clicks IS NULL AND active_from IS NULL
+  AND interstitial_reason IS NULL AND redirect_type IS NULL
+  AND ios_url IS NULL AND android_url IS NULL AND desktop_url IS NULL AND app_url IS NULL
+  AND geo_targeted = false AND variant_mode IS NULL AND schedule_routed = false
+  AND forward_query IS NULL AND forward_path = false
+ORDER BY canonical_url, created_at DESC
+`
+
+type ListActiveURLsByCanonicalURLsRow struct {
+	ShortCode    string           `json:"shortCode"`
+	CanonicalUrl string           `json:"canonicalUrl"`
+	ExpiresAt    pgtype.Timestamp `json:"expiresAt"`
+}
+
+func (q *Queries) ListActiveURLsByCanonicalURLs(ctx context.Context, canonicalUrls []string) ([]ListActiveURLsByCanonicalURLsRow, error) {
+	rows, err := q.db.Query(ctx, listActiveURLsByCanonicalURLs, canonicalUrls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListActiveURLsByCanonicalURLsRow{}
+	for rows.Next() {
+		var i ListActiveURLsByCanonicalURLsRow
+		if err := rows.Scan(&i.ShortCode, &i.CanonicalUrl, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShortCodeCaseConflicts = `-- name: ListShortCodeCaseConflicts :many
+SELECT
+    lower(short_code)::text AS folded_code,
+    array_agg(short_code ORDER BY created_at)::varchar[] AS short_codes
+FROM urls
+GROUP BY lower(short_code)
+HAVING count(*) > 1
+ORDER BY folded_code
+`
+
+type ListShortCodeCaseConflictsRow struct {
+	FoldedCode string   `json:"foldedCode"`
+	ShortCodes []string `json:"shortCodes"`
+}
+
+func (q *Queries) ListShortCodeCaseConflicts(ctx context.Context) ([]ListShortCodeCaseConflictsRow, error) {
+	rows, err := q.db.Query(ctx, listShortCodeCaseConflicts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListShortCodeCaseConflictsRow{}
+	for rows.Next() {
+		var i ListShortCodeCaseConflictsRow
+		if err := rows.Scan(&i.FoldedCode, &i.ShortCodes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listURLs = `-- name: ListURLs :many
-SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active FROM urls
-WHERE is_active = true
+SELECT id, short_code, original_url, created_at, updated_at, expires_at, click_count, is_active, canonical_url, password_hash, max_clicks, active_from, abuse_status, interstitial_reason, redirect_type, ios_url, android_url, desktop_url, app_url, geo_targeted, variant_mode, timezone, schedule_routed, forward_query, forward_path FROM urls
+WHERE (is_active = true OR expires_at <= (NOW() AT TIME ZONE 'UTC'))
+  AND (
+    $1::text IS NULL
+    OR ($1 = 'scheduled' AND active_from > (NOW() AT TIME ZONE 'UTC'))
+    OR ($1 = 'expired' AND expires_at <= (NOW() AT TIME ZONE 'UTC'))
+    OR ($1 = 'live'
+        AND (active_from IS NULL OR active_from <= (NOW() AT TIME ZONE 'UTC'))
+        AND (expires_at IS NULL OR expires_at > (NOW() AT TIME ZONE 'UTC')))
+  )
 ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+LIMIT $2 OFFSET $3
 `
 
 type ListURLsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	State  pgtype.Text `json:"state"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
 }
 
 func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error) {
-	rows, err := q.db.Query(ctx, listURLs, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listURLs, arg.State, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +677,23 @@ func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, erro
 			&i.ExpiresAt,
 			&i.ClickCount,
 			&i.IsActive,
+			&i.CanonicalUrl,
+			&i.PasswordHash,
+			&i.MaxClicks,
+			&i.ActiveFrom,
+			&i.AbuseStatus,
+			&i.InterstitialReason,
+			&i.RedirectType,
+			&i.IosUrl,
+			&i.AndroidUrl,
+			&i.DesktopUrl,
+			&i.AppUrl,
+			&i.GeoTargeted,
+			&i.VariantMode,
+			&i.Timezone,
+			&i.ScheduleRouted,
+			&i.ForwardQuery,
+			&i.ForwardPath,
 		); err != nil {
 			return nil, err
 		}
@@ -180,3 +704,203 @@ func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, erro
 	}
 	return items, nil
 }
+
+const listUncanonicalizedURLsAfterID = `-- name: ListUncanonicalizedURLsAfterID :many
+SELECT id, original_url
+FROM urls
+WHERE is_active = true AND canonical_url = original_url AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListUncanonicalizedURLsAfterIDParams struct {
+	ID    int64 `json:"id"`
+	Limit int32 `json:"limit"`
+}
+
+type ListUncanonicalizedURLsAfterIDRow struct {
+	ID          int64  `json:"id"`
+	OriginalUrl string `json:"originalUrl"`
+}
+
+func (q *Queries) ListUncanonicalizedURLsAfterID(ctx context.Context, arg ListUncanonicalizedURLsAfterIDParams) ([]ListUncanonicalizedURLsAfterIDRow, error) {
+	rows, err := q.db.Query(ctx, listUncanonicalizedURLsAfterID, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUncanonicalizedURLsAfterIDRow{}
+	for rows.Next() {
+		var i ListUncanonicalizedURLsAfterIDRow
+		if err := rows.Scan(&i.ID, &i.OriginalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nextShortCodeIDs = `-- name: NextShortCodeIDs :many
+SELECT nextval('short_code_seq')::bigint AS id
+FROM generate_series(1, $1::int)
+`
+
+func (q *Queries) NextShortCodeIDs(ctx context.Context, count int32) ([]int64, error) {
+	rows, err := q.db.Query(ctx, nextShortCodeIDs, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const quarantineURL = `-- name: QuarantineURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'threat_feed', updated_at = NOW()
+WHERE id = $1 AND abuse_status IS NULL
+`
+
+func (q *Queries) QuarantineURL(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, quarantineURL, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reinstateURL = `-- name: ReinstateURL :execrows
+UPDATE urls
+SET is_active = true, abuse_status = NULL, updated_at = NOW()
+WHERE id = $1 AND abuse_status IN ('suspended', 'threat_feed')
+`
+
+func (q *Queries) ReinstateURL(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, reinstateURL, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setURLCanonical = `-- name: SetURLCanonical :exec
+UPDATE urls
+SET canonical_url = $2
+WHERE id = $1
+`
+
+type SetURLCanonicalParams struct {
+	ID           int64  `json:"id"`
+	CanonicalUrl string `json:"canonicalUrl"`
+}
+
+func (q *Queries) SetURLCanonical(ctx context.Context, arg SetURLCanonicalParams) error {
+	_, err := q.db.Exec(ctx, setURLCanonical, arg.ID, arg.CanonicalUrl)
+	return err
+}
+
+const setURLGeoTargeted = `-- name: SetURLGeoTargeted :exec
+UPDATE urls
+SET geo_targeted = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetURLGeoTargetedParams struct {
+	ID          int64 `json:"id"`
+	GeoTargeted bool  `json:"geoTargeted"`
+}
+
+func (q *Queries) SetURLGeoTargeted(ctx context.Context, arg SetURLGeoTargetedParams) error {
+	_, err := q.db.Exec(ctx, setURLGeoTargeted, arg.ID, arg.GeoTargeted)
+	return err
+}
+
+const setURLInterstitial = `-- name: SetURLInterstitial :execrows
+UPDATE urls
+SET interstitial_reason = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetURLInterstitialParams struct {
+	ID                 int64       `json:"id"`
+	InterstitialReason pgtype.Text `json:"interstitialReason"`
+}
+
+func (q *Queries) SetURLInterstitial(ctx context.Context, arg SetURLInterstitialParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setURLInterstitial, arg.ID, arg.InterstitialReason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setURLSchedule = `-- name: SetURLSchedule :exec
+UPDATE urls
+SET schedule_routed = $2, timezone = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetURLScheduleParams struct {
+	ID             int64       `json:"id"`
+	ScheduleRouted bool        `json:"scheduleRouted"`
+	Timezone       pgtype.Text `json:"timezone"`
+}
+
+func (q *Queries) SetURLSchedule(ctx context.Context, arg SetURLScheduleParams) error {
+	_, err := q.db.Exec(ctx, setURLSchedule, arg.ID, arg.ScheduleRouted, arg.Timezone)
+	return err
+}
+
+const setURLVariantMode = `-- name: SetURLVariantMode :exec
+UPDATE urls
+SET variant_mode = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetURLVariantModeParams struct {
+	ID          int64       `json:"id"`
+	VariantMode pgtype.Text `json:"variantMode"`
+}
+
+func (q *Queries) SetURLVariantMode(ctx context.Context, arg SetURLVariantModeParams) error {
+	_, err := q.db.Exec(ctx, setURLVariantMode, arg.ID, arg.VariantMode)
+	return err
+}
+
+const suspendURL = `-- name: SuspendURL :execrows
+UPDATE urls
+SET is_active = false, abuse_status = 'suspended', updated_at = NOW()
+WHERE id = $1 AND is_active = true AND abuse_status IS NULL
+`
+
+func (q *Queries) SuspendURL(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, suspendURL, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const tryAdvisoryXactLock = `-- name: TryAdvisoryXactLock :one
+SELECT pg_try_advisory_xact_lock($1::bigint) AS locked
+`
+
+func (q *Queries) TryAdvisoryXactLock(ctx context.Context, lockID int64) (bool, error) {
+	row := q.db.QueryRow(ctx, tryAdvisoryXactLock, lockID)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
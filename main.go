@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"url-shortener/api"
 	db "url-shortener/db/sqlc"
 	"url-shortener/utils"
+	"url-shortener/worker"
+
+	// The runtime image has no zoneinfo, link timezones resolve from the
+	// copy embedded in the binary.
+	_ "time/tzdata"
 )
 
 func main() {
@@ -22,7 +28,33 @@ func main() {
 		panic(err)
 	}
 
-	server := api.NewServer(&config, store)
+	go worker.BackfillCanonicalURLs(context.Background(), store, utils.CanonicalizeOptions{
+		SortQuery:           config.CanonicalSortQuery,
+		StripTrackingParams: config.CanonicalStripTracking,
+	})
+
+	sweeper := worker.NewSweeper(&config, store)
+	go sweeper.Start(context.Background())
+
+	threatFeed, err := utils.NewThreatFeed(config.ThreatFeedFile)
+	if err != nil {
+		log.Fatal("cannot load threat feed:", err)
+	}
+
+	screener := worker.NewScreener(&config, store, threatFeed)
+	go screener.Start(context.Background())
+
+	bannedDomains := utils.NewBannedDomainCheck(nil)
+	bannedDomainSync := worker.NewBannedDomainSync(&config, store, bannedDomains)
+	if err := bannedDomainSync.Reload(context.Background()); err != nil {
+		log.Fatal("cannot load banned domains:", err)
+	}
+	go bannedDomainSync.Start(context.Background())
+
+	server, err := api.NewServer(&config, store, sweeper, threatFeed, bannedDomains)
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
 
 	var ServerAddress = config.HttpServerAddress
 
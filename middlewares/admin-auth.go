@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth guards admin routes with a static bearer token. With no token
+// configured the admin API is disabled entirely.
+func AdminAuth(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Admin API is disabled. Set ADMIN_TOKEN to enable it.",
+			})
+			c.Abort()
+			return
+		}
+
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid admin token",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
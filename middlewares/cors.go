@@ -19,7 +19,7 @@ func CORS(frontendUrl string) gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{frontendOrigin}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
 
 	return cors.New(config)
 }
@@ -13,15 +13,15 @@ type client struct {
 	lastReset time.Time
 }
 
-type rateLimiter struct {
+type RateLimiter struct {
 	clients map[string]*client
 	mu      sync.Mutex
 	rate    int
 	window  time.Duration
 }
 
-func newRateLimiter(rate int, window time.Duration) *rateLimiter {
-	rl := &rateLimiter{
+func NewRateLimiter(rate int, window time.Duration) *RateLimiter {
+	rl := &RateLimiter{
 		clients: make(map[string]*client),
 		rate:    rate,
 		window:  window,
@@ -30,7 +30,7 @@ func newRateLimiter(rate int, window time.Duration) *rateLimiter {
 	return rl
 }
 
-func (rl *rateLimiter) cleanupClients() {
+func (rl *RateLimiter) cleanupClients() {
 	for {
 		time.Sleep(rl.window)
 		rl.mu.Lock()
@@ -43,7 +43,7 @@ func (rl *rateLimiter) cleanupClients() {
 	}
 }
 
-func (rl *rateLimiter) allow(ip string) bool {
+func (rl *RateLimiter) Allow(ip string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -70,13 +70,27 @@ func (rl *rateLimiter) allow(ip string) bool {
 	return false
 }
 
-var limiter = newRateLimiter(60, time.Minute) // 60 requests per minute
+// Blocked reports whether key has used up its quota for the current window,
+// without counting a new request.
+func (rl *RateLimiter) Blocked(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	c, exists := rl.clients[key]
+	if !exists || time.Since(c.lastReset) > rl.window {
+		return false
+	}
+
+	return c.count >= rl.rate
+}
+
+var limiter = NewRateLimiter(60, time.Minute) // 60 requests per minute
 
 func RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
 
-		if !limiter.allow(ip) {
+		if !limiter.Allow(ip) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": "Rate limit exceeded. Please try again later.",
 			})
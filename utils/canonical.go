@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that identify a campaign or click
+// rather than the destination itself.
+var trackingParams = map[string]struct{}{
+	"fbclid":  {},
+	"gclid":   {},
+	"dclid":   {},
+	"msclkid": {},
+	"mc_cid":  {},
+	"mc_eid":  {},
+	"igshid":  {},
+}
+
+type CanonicalizeOptions struct {
+	SortQuery           bool
+	StripTrackingParams bool
+}
+
+// CanonicalizeURL normalizes an http(s) URL so that equivalent inputs map to
+// the same string: scheme and host are lowercased, default ports are dropped,
+// "." and ".." path segments are resolved (unless the path has an escaped
+// slash) and an empty path becomes "/".
+// Query sorting and tracking-parameter removal are opt-in.
+func CanonicalizeURL(raw string, opts CanonicalizeOptions) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = canonicalHost(u.Scheme, u.Host)
+
+	// Resolving against an empty reference removes dot segments (RFC 3986).
+	// Servers disagree on whether %2F separates segments, so such a path is
+	// left alone rather than risk mapping two resources to one URL.
+	if !strings.Contains(strings.ToLower(u.EscapedPath()), "%2f") {
+		u = u.ResolveReference(&url.URL{})
+	}
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	if u.Fragment == "" {
+		u.RawFragment = ""
+	}
+
+	if u.RawQuery != "" && (opts.SortQuery || opts.StripTrackingParams) {
+		u.RawQuery = canonicalQuery(u.RawQuery, opts)
+	}
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+
+	return u.String(), nil
+}
+
+func canonicalHost(scheme, host string) string {
+	host = strings.ToLower(host)
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		if strings.Contains(hostname, ":") {
+			return "[" + hostname + "]"
+		}
+		return hostname
+	}
+
+	return host
+}
+
+func canonicalQuery(rawQuery string, opts CanonicalizeOptions) string {
+	pairs := strings.Split(rawQuery, "&")
+	kept := pairs[:0]
+
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		if opts.StripTrackingParams && isTrackingParam(pair) {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+
+	if opts.SortQuery {
+		// Stable so repeated keys keep their relative order.
+		sort.SliceStable(kept, func(i, j int) bool {
+			return queryKey(kept[i]) < queryKey(kept[j])
+		})
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func isTrackingParam(pair string) bool {
+	key := strings.ToLower(queryKey(pair))
+	if strings.HasPrefix(key, "utm_") {
+		return true
+	}
+	_, tracking := trackingParams[key]
+	return tracking
+}
+
+func queryKey(pair string) string {
+	key, _, _ := strings.Cut(pair, "=")
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		return unescaped
+	}
+	return key
+}
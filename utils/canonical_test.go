@@ -0,0 +1,40 @@
+package utils
+
+import "testing"
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		opts CanonicalizeOptions
+		want string
+	}{
+		{"lowercases scheme and host", "HTTP://Example.COM/Path", CanonicalizeOptions{}, "http://example.com/Path"},
+		{"drops default http port", "http://example.com:80/a", CanonicalizeOptions{}, "http://example.com/a"},
+		{"drops default https port", "https://example.com:443/a", CanonicalizeOptions{}, "https://example.com/a"},
+		{"keeps other ports", "https://example.com:8443/a", CanonicalizeOptions{}, "https://example.com:8443/a"},
+		{"keeps ipv6 brackets", "http://[::1]:80/a", CanonicalizeOptions{}, "http://[::1]/a"},
+		{"empty path", "https://example.com", CanonicalizeOptions{}, "https://example.com/"},
+		{"dot segments", "http://ex.com/a/./b/../c", CanonicalizeOptions{}, "http://ex.com/a/c"},
+		{"dot segments above root", "http://ex.com/../a", CanonicalizeOptions{}, "http://ex.com/a"},
+		{"escaped slash is not a separator", "http://ex.com/a%2Fb/../c", CanonicalizeOptions{}, "http://ex.com/a%2Fb/../c"},
+		{"keeps escaped slash", "http://ex.com/a%2Fb/c", CanonicalizeOptions{}, "http://ex.com/a%2Fb/c"},
+		{"drops empty query", "http://ex.com/a?", CanonicalizeOptions{}, "http://ex.com/a"},
+		{"keeps query order by default", "http://ex.com/?b=2&a=1", CanonicalizeOptions{}, "http://ex.com/?b=2&a=1"},
+		{"sorts query", "http://ex.com/?b=2&a=1&b=1", CanonicalizeOptions{SortQuery: true}, "http://ex.com/?a=1&b=2&b=1"},
+		{"strips tracking", "http://ex.com/?utm_source=x&id=7&fbclid=y", CanonicalizeOptions{StripTrackingParams: true}, "http://ex.com/?id=7"},
+		{"strips only tracking", "http://ex.com/?UTM_Medium=x", CanonicalizeOptions{StripTrackingParams: true}, "http://ex.com/"},
+		{"keeps fragment", "http://ex.com/a#top", CanonicalizeOptions{}, "http://ex.com/a#top"},
+	}
+
+	for _, tt := range tests {
+		got, err := CanonicalizeURL(tt.raw, tt.opts)
+		if err != nil {
+			t.Errorf("%s: CanonicalizeURL(%q) returned %v", tt.name, tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanonicalizeURL(%q) = %q, want %q", tt.name, tt.raw, got, tt.want)
+		}
+	}
+}
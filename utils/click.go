@@ -32,7 +32,46 @@ func DetectDeviceType(userAgent string) string {
 	return "desktop"
 }
 
-func GetCountryFromIP(ip string) string {
+// Platforms a link can target with its own destination.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+)
+
+// DetectPlatform returns the operating system family a visitor is on, or an
+// empty string for other mobile devices.
+func DetectPlatform(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
+		return PlatformIOS
+	case DetectDeviceType(userAgent) == "desktop":
+		return PlatformDesktop
+	}
+	return ""
+}
+
+// GetCountry returns the visitor's ISO 3166-1 alpha-2 country code as set by
+// a trusted CDN or proxy in header (Cloudflare's CF-IPCountry, for example),
+// or an empty string when header is not configured or names no country.
+func GetCountry(ctx *gin.Context, header string) string {
+	if header == "" {
+		return ""
+	}
 
-	return "VN"
+	country := strings.ToUpper(strings.TrimSpace(ctx.GetHeader(header)))
+	// XX is what CDNs send when they could not place the address.
+	if len(country) != 2 || country == "XX" {
+		return ""
+	}
+	for _, c := range country {
+		if c < 'A' || c > 'Z' {
+			return ""
+		}
+	}
+	return country
 }
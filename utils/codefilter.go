@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// builtinReservedCodes are paths that are (or may become) top-level routes and
+// therefore can never be handed out as a short code or custom alias. They
+// cannot be removed at runtime.
+var builtinReservedCodes = []string{
+	"api", "admin", "health", "favicon.ico", "static", "assets", "metrics",
+	"login", "logout", "signup", "robots.txt", "sitemap.xml",
+}
+
+// leetReplacer folds common digit-for-letter substitutions so a blocked word
+// is still caught when a random code spells it with numbers.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "@", "a", "$", "s",
+)
+
+var (
+	ErrReservedCode = errors.New("code is reserved")
+	ErrBlockedCode  = errors.New("code contains a blocked word")
+	ErrEmptyWord    = errors.New("word must not be empty")
+)
+
+// CodeFilterLists is the on-disk (JSON) and API representation of the
+// editable lists.
+type CodeFilterLists struct {
+	Reserved []string `json:"reserved"`
+	Blocked  []string `json:"blocked"`
+}
+
+// CodeFilter rejects short codes that collide with reserved paths (exact,
+// case-insensitive match) or contain a blocked word. Generated codes are
+// matched on substrings after lowercasing and folding leetspeak; aliases only
+// on whole tokens (see filterTokens), so a custom "classic" is not refused
+// for containing a blocked "ass". It is safe for concurrent use and can be
+// edited at runtime; edits are written back to the file it was loaded from
+// before they take effect.
+type CodeFilter struct {
+	mu       sync.RWMutex
+	path     string
+	reserved map[string]struct{}
+	blocked  map[string]struct{}
+}
+
+// NewCodeFilter loads the lists from path. An empty path or a missing file
+// starts with only the built-in reserved words.
+func NewCodeFilter(path string) (*CodeFilter, error) {
+	filter := &CodeFilter{
+		path:     path,
+		reserved: make(map[string]struct{}),
+		blocked:  make(map[string]struct{}),
+	}
+
+	if path == "" {
+		return filter, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return filter, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read code filter file: %w", err)
+	}
+
+	var lists CodeFilterLists
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, fmt.Errorf("cannot parse code filter file: %w", err)
+	}
+
+	filter.reserved, filter.blocked = filterSets(lists)
+	return filter, nil
+}
+
+// Check returns ErrReservedCode or ErrBlockedCode when an alias must not be
+// used.
+func (f *CodeFilter) Check(code string) error {
+	return f.check(code, false)
+}
+
+// Allowed reports whether a generated code may be handed out. A false
+// positive only costs another candidate, so blocked words are caught anywhere
+// in the code, "xfuck12" as well as "ab3fUcKz".
+func (f *CodeFilter) Allowed(code string) bool {
+	return f.check(code, true) == nil
+}
+
+func (f *CodeFilter) check(code string, substrings bool) error {
+	lower := strings.ToLower(code)
+
+	for _, reserved := range builtinReservedCodes {
+		if lower == reserved {
+			return ErrReservedCode
+		}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, reserved := f.reserved[lower]; reserved {
+		return ErrReservedCode
+	}
+
+	if substrings {
+		folded := leetReplacer.Replace(lower)
+		for word := range f.blocked {
+			if strings.Contains(lower, word) || strings.Contains(folded, word) {
+				return ErrBlockedCode
+			}
+		}
+		return nil
+	}
+
+	for _, token := range filterTokens(lower) {
+		if _, blocked := f.blocked[token]; blocked {
+			return ErrBlockedCode
+		}
+	}
+
+	return nil
+}
+
+// filterTokens splits a lowercased code into the words blocked words are
+// matched against: its runs of letters ("shit" in "shit42") and its parts
+// between "-" and "_" with leetspeak folded ("sh1t" in "sh1t-happens").
+func filterTokens(lower string) []string {
+	tokens := strings.FieldsFunc(lower, func(r rune) bool { return r < 'a' || r > 'z' })
+	for _, part := range strings.FieldsFunc(lower, func(r rune) bool { return r == '-' || r == '_' }) {
+		tokens = append(tokens, leetReplacer.Replace(part))
+	}
+	return tokens
+}
+
+func (f *CodeFilter) BuiltinReserved() []string {
+	return append([]string(nil), builtinReservedCodes...)
+}
+
+func (f *CodeFilter) Lists() CodeFilterLists {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return CodeFilterLists{
+		Reserved: sortedKeys(f.reserved),
+		Blocked:  sortedKeys(f.blocked),
+	}
+}
+
+// Replace swaps both lists and persists them. The lists in use only change
+// once the file was written.
+func (f *CodeFilter) Replace(lists CodeFilterLists) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reserved, blocked := filterSets(lists)
+	if err := f.save(reserved, blocked); err != nil {
+		return err
+	}
+	f.reserved, f.blocked = reserved, blocked
+	return nil
+}
+
+// AddReserved and AddBlocked return ErrEmptyWord for a blank word.
+func (f *CodeFilter) AddReserved(word string) error {
+	if word = normalizeFilterWord(word); word == "" {
+		return ErrEmptyWord
+	}
+	return f.update(func(reserved, blocked map[string]struct{}) { reserved[word] = struct{}{} })
+}
+
+func (f *CodeFilter) RemoveReserved(word string) error {
+	return f.update(func(reserved, blocked map[string]struct{}) { delete(reserved, normalizeFilterWord(word)) })
+}
+
+func (f *CodeFilter) AddBlocked(word string) error {
+	if word = normalizeFilterWord(word); word == "" {
+		return ErrEmptyWord
+	}
+	return f.update(func(reserved, blocked map[string]struct{}) { blocked[word] = struct{}{} })
+}
+
+func (f *CodeFilter) RemoveBlocked(word string) error {
+	return f.update(func(reserved, blocked map[string]struct{}) { delete(blocked, normalizeFilterWord(word)) })
+}
+
+// update applies fn to copies of the lists and swaps them in once saved.
+func (f *CodeFilter) update(fn func(reserved, blocked map[string]struct{})) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reserved, blocked := maps.Clone(f.reserved), maps.Clone(f.blocked)
+	fn(reserved, blocked)
+	if err := f.save(reserved, blocked); err != nil {
+		return err
+	}
+	f.reserved, f.blocked = reserved, blocked
+	return nil
+}
+
+func filterSets(lists CodeFilterLists) (map[string]struct{}, map[string]struct{}) {
+	reserved := make(map[string]struct{}, len(lists.Reserved))
+	for _, word := range lists.Reserved {
+		if word = normalizeFilterWord(word); word != "" {
+			reserved[word] = struct{}{}
+		}
+	}
+
+	blocked := make(map[string]struct{}, len(lists.Blocked))
+	for _, word := range lists.Blocked {
+		if word = normalizeFilterWord(word); word != "" {
+			blocked[word] = struct{}{}
+		}
+	}
+
+	return reserved, blocked
+}
+
+func (f *CodeFilter) save(reserved, blocked map[string]struct{}) error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(CodeFilterLists{
+		Reserved: sortedKeys(reserved),
+		Blocked:  sortedKeys(blocked),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write code filter file: %w", err)
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func normalizeFilterWord(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
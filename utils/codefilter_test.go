@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestCodeFilter(t *testing.T) *CodeFilter {
+	t.Helper()
+
+	filter, err := NewCodeFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.Replace(CodeFilterLists{
+		Reserved: []string{"pricing"},
+		Blocked:  []string{"fuck", "shit", "ass"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return filter
+}
+
+func TestCodeFilterCheck(t *testing.T) {
+	filter := newTestCodeFilter(t)
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"hello", nil},
+		{"API", ErrReservedCode},
+		{"health", ErrReservedCode},
+		{"Pricing", ErrReservedCode},
+		{"pricing-page", nil},
+		{"shit", ErrBlockedCode},
+		{"shit42", ErrBlockedCode},
+		{"sh1t-happens", ErrBlockedCode},
+		{"my_5h1t", ErrBlockedCode},
+		{"classic", nil},
+		{"bass-guitar", nil},
+		{"xfuck12", nil},
+	}
+
+	for _, tt := range tests {
+		if err := filter.Check(tt.code); !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q) = %v, want %v", tt.code, err, tt.want)
+		}
+	}
+}
+
+func TestCodeFilterAllowed(t *testing.T) {
+	filter := newTestCodeFilter(t)
+
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"aB3xYz9", true},
+		{"admin", false},
+		{"PRICING", false},
+		{"fuckQ", false},
+		{"xfuck12", false},
+		{"ab3fUcKz", false},
+		{"aBshitX", false},
+		{"q5h1tq", false},
+		{"cla55ic", false},
+	}
+
+	for _, tt := range tests {
+		if got := filter.Allowed(tt.code); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCodeFilterRejectsEmptyWords(t *testing.T) {
+	filter := newTestCodeFilter(t)
+
+	for _, word := range []string{"", "   "} {
+		if err := filter.AddBlocked(word); !errors.Is(err, ErrEmptyWord) {
+			t.Errorf("AddBlocked(%q) = %v, want ErrEmptyWord", word, err)
+		}
+		if err := filter.AddReserved(word); !errors.Is(err, ErrEmptyWord) {
+			t.Errorf("AddReserved(%q) = %v, want ErrEmptyWord", word, err)
+		}
+	}
+
+	if !filter.Allowed("abcdefg") {
+		t.Error("an empty word must not block every code")
+	}
+}
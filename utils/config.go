@@ -1,14 +1,52 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	HttpServerAddress string `mapstructure:"HTTP_SERVER_ADDRESS"`
-	DBSOURCE          string `mapstructure:"DB_SOURCE"`
-	BaseURL           string `mapstructure:"BASE_URL"`
-	FrontendURL       string `mapstructure:"FRONTEND_URL"`
+	HttpServerAddress        string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	DBSOURCE                 string        `mapstructure:"DB_SOURCE"`
+	BaseURL                  string        `mapstructure:"BASE_URL"`
+	FrontendURL              string        `mapstructure:"FRONTEND_URL"`
+	SweeperInterval          time.Duration `mapstructure:"SWEEPER_INTERVAL"`
+	SweeperBatchSize         int32         `mapstructure:"SWEEPER_BATCH_SIZE"`
+	InactiveRetention        time.Duration `mapstructure:"INACTIVE_RETENTION"`
+	DeduplicateURLs          bool          `mapstructure:"DEDUPLICATE_URLS"`
+	CanonicalSortQuery       bool          `mapstructure:"CANONICAL_SORT_QUERY"`
+	CanonicalStripTracking   bool          `mapstructure:"CANONICAL_STRIP_TRACKING"`
+	CookieSecret             string        `mapstructure:"COOKIE_SECRET"`
+	UnlockCookieTTL          time.Duration `mapstructure:"UNLOCK_COOKIE_TTL"`
+	ClickLimitFallbackURL    string        `mapstructure:"CLICK_LIMIT_FALLBACK_URL"`
+	ScheduledLinkFallbackURL string        `mapstructure:"SCHEDULED_LINK_FALLBACK_URL"`
+	CodeStrategy             string        `mapstructure:"CODE_STRATEGY"`
+	CodePermutationKey       string        `mapstructure:"CODE_PERMUTATION_KEY"`
+	CodeAlphabet             string        `mapstructure:"CODE_ALPHABET"`
+	CodeLength               int           `mapstructure:"CODE_LENGTH"`
+	CodeMaxLength            int           `mapstructure:"CODE_MAX_LENGTH"`
+	CodeGrowthThreshold      float64       `mapstructure:"CODE_GROWTH_THRESHOLD"`
+	CodeFilterFile           string        `mapstructure:"CODE_FILTER_FILE"`
+	CaseInsensitiveCodes     bool          `mapstructure:"CASE_INSENSITIVE_CODES"`
+	DestinationDenyDomains   string        `mapstructure:"DESTINATION_DENY_DOMAINS"`
+	DestinationAllowDomains  string        `mapstructure:"DESTINATION_ALLOW_DOMAINS"`
+	DestinationWarnDomains   string        `mapstructure:"DESTINATION_WARN_DOMAINS"`
+	AllowURLShorteners       bool          `mapstructure:"ALLOW_URL_SHORTENERS"`
+	ExtraURLShorteners       string        `mapstructure:"EXTRA_URL_SHORTENERS"`
+	ThreatFeedFile           string        `mapstructure:"THREAT_FEED_FILE"`
+	ThreatFeedInterval       time.Duration `mapstructure:"THREAT_FEED_INTERVAL"`
+	AbuseReportThreshold     int           `mapstructure:"ABUSE_REPORT_THRESHOLD"`
+	InterstitialThreshold    int           `mapstructure:"INTERSTITIAL_REPORT_THRESHOLD"`
+	TemplateDir              string        `mapstructure:"TEMPLATE_DIR"`
+	QRLogoFile               string        `mapstructure:"QR_LOGO_FILE"`
+	AppLinkSchemes           string        `mapstructure:"APP_LINK_SCHEMES"`
+	WordCodeWords            int           `mapstructure:"WORD_CODE_WORDS"`
+	WordCodeDigits           int           `mapstructure:"WORD_CODE_DIGITS"`
+	AdminToken               string        `mapstructure:"ADMIN_TOKEN"`
+	CountryHeader            string        `mapstructure:"COUNTRY_HEADER"`
+	TrustedProxies           string        `mapstructure:"TRUSTED_PROXIES"`
+	BannedDomainsInterval    time.Duration `mapstructure:"BANNED_DOMAINS_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -18,10 +56,49 @@ func LoadConfig(path string) (config Config, err error) {
 	_ = viper.ReadInConfig()
 
 	viper.AutomaticEnv()
+	// 0 is a valid setting (no numeric suffix), so "use the default" needs
+	// a value of its own.
+	viper.SetDefault("WORD_CODE_DIGITS", -1)
 	viper.BindEnv("HTTP_SERVER_ADDRESS")
 	viper.BindEnv("DB_SOURCE")
 	viper.BindEnv("BASE_URL")
 	viper.BindEnv("FRONTEND_URL")
+	viper.BindEnv("SWEEPER_INTERVAL")
+	viper.BindEnv("SWEEPER_BATCH_SIZE")
+	viper.BindEnv("INACTIVE_RETENTION")
+	viper.BindEnv("DEDUPLICATE_URLS")
+	viper.BindEnv("CANONICAL_SORT_QUERY")
+	viper.BindEnv("CANONICAL_STRIP_TRACKING")
+	viper.BindEnv("COOKIE_SECRET")
+	viper.BindEnv("UNLOCK_COOKIE_TTL")
+	viper.BindEnv("CLICK_LIMIT_FALLBACK_URL")
+	viper.BindEnv("SCHEDULED_LINK_FALLBACK_URL")
+	viper.BindEnv("CODE_STRATEGY")
+	viper.BindEnv("CODE_PERMUTATION_KEY")
+	viper.BindEnv("CODE_ALPHABET")
+	viper.BindEnv("CODE_LENGTH")
+	viper.BindEnv("CODE_MAX_LENGTH")
+	viper.BindEnv("CODE_GROWTH_THRESHOLD")
+	viper.BindEnv("CODE_FILTER_FILE")
+	viper.BindEnv("CASE_INSENSITIVE_CODES")
+	viper.BindEnv("DESTINATION_DENY_DOMAINS")
+	viper.BindEnv("DESTINATION_ALLOW_DOMAINS")
+	viper.BindEnv("DESTINATION_WARN_DOMAINS")
+	viper.BindEnv("ALLOW_URL_SHORTENERS")
+	viper.BindEnv("EXTRA_URL_SHORTENERS")
+	viper.BindEnv("THREAT_FEED_FILE")
+	viper.BindEnv("THREAT_FEED_INTERVAL")
+	viper.BindEnv("ABUSE_REPORT_THRESHOLD")
+	viper.BindEnv("INTERSTITIAL_REPORT_THRESHOLD")
+	viper.BindEnv("TEMPLATE_DIR")
+	viper.BindEnv("QR_LOGO_FILE")
+	viper.BindEnv("APP_LINK_SCHEMES")
+	viper.BindEnv("WORD_CODE_WORDS")
+	viper.BindEnv("WORD_CODE_DIGITS")
+	viper.BindEnv("ADMIN_TOKEN")
+	viper.BindEnv("COUNTRY_HEADER")
+	viper.BindEnv("TRUSTED_PROXIES")
+	viper.BindEnv("BANNED_DOMAINS_INTERVAL")
 
 	err = viper.Unmarshal(&config)
 	return
@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Rejection reasons returned by the destination checks.
+const (
+	ReasonInvalidURL       = "invalid_url"
+	ReasonDeniedDomain     = "denied_domain"
+	ReasonDomainNotAllowed = "domain_not_allowed"
+	ReasonURLShortener     = "url_shortener"
+	ReasonSelfReference    = "self_reference"
+	ReasonThreatMatch      = "threat_match"
+	ReasonBannedDomain     = "banned_domain"
+)
+
+// knownShorteners are public URL shorteners. Shortening their links only
+// hides the real destination behind two hops.
+var knownShorteners = []string{
+	"bit.ly", "bitly.com", "buff.ly", "cutt.ly", "goo.gl", "is.gd", "lnkd.in",
+	"ow.ly", "rb.gy", "rebrand.ly", "s.id", "shorturl.at", "t.co", "t.ly",
+	"tiny.cc", "tinyurl.com", "v.gd",
+}
+
+// DestinationRejection explains why a destination URL was refused.
+type DestinationRejection struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// DestinationCheck is one rule of a DestinationPolicy. It returns nil when
+// the URL passes.
+type DestinationCheck interface {
+	Check(u *url.URL) *DestinationRejection
+}
+
+// DestinationPolicy runs a destination through its checks in order and stops
+// at the first rejection. Warning checks work the same way, but a match only
+// means visitors should be warned before they are sent on.
+type DestinationPolicy struct {
+	checks   []DestinationCheck
+	warnings []DestinationCheck
+}
+
+func NewDestinationPolicy(checks ...DestinationCheck) *DestinationPolicy {
+	return &DestinationPolicy{checks: checks}
+}
+
+// WithWarnings adds checks whose matches are allowed but flagged.
+func (p *DestinationPolicy) WithWarnings(checks ...DestinationCheck) *DestinationPolicy {
+	p.warnings = append(p.warnings, checks...)
+	return p
+}
+
+func (p *DestinationPolicy) Check(raw string) *DestinationRejection {
+	return runDestinationChecks(raw, p.checks)
+}
+
+// Warning returns the first warning check that matches, or nil.
+func (p *DestinationPolicy) Warning(raw string) *DestinationRejection {
+	return runDestinationChecks(raw, p.warnings)
+}
+
+func runDestinationChecks(raw string, checks []DestinationCheck) *DestinationRejection {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return &DestinationRejection{Reason: ReasonInvalidURL, Message: "Invalid URL format"}
+	}
+
+	for _, check := range checks {
+		if rejection := check.Check(u); rejection != nil {
+			return rejection
+		}
+	}
+	return nil
+}
+
+// DomainListCheck refuses hosts on the deny list and, when the allow list is
+// not empty, every host that is not on it. Entries match the domain itself
+// and all of its subdomains.
+type DomainListCheck struct {
+	deny  []string
+	allow []string
+}
+
+// NewDomainListCheck takes comma-separated domain lists, as they come from
+// the environment.
+func NewDomainListCheck(deny, allow string) *DomainListCheck {
+	return &DomainListCheck{deny: splitDomains(deny), allow: splitDomains(allow)}
+}
+
+func (c *DomainListCheck) Check(u *url.URL) *DestinationRejection {
+	host := normalizeHost(u.Hostname())
+
+	if matchesDomain(host, c.deny) {
+		return &DestinationRejection{
+			Reason:  ReasonDeniedDomain,
+			Message: fmt.Sprintf("Links to %s are not allowed", host),
+		}
+	}
+	if len(c.allow) > 0 && !matchesDomain(host, c.allow) {
+		return &DestinationRejection{
+			Reason:  ReasonDomainNotAllowed,
+			Message: fmt.Sprintf("%s is not on the list of allowed domains", host),
+		}
+	}
+	return nil
+}
+
+// ShortenerCheck refuses links to other URL shorteners.
+type ShortenerCheck struct {
+	domains []string
+}
+
+// NewShortenerCheck uses the built-in list of public shorteners plus any
+// extra comma-separated domains.
+func NewShortenerCheck(extra string) *ShortenerCheck {
+	return &ShortenerCheck{domains: append(splitDomains(extra), knownShorteners...)}
+}
+
+func (c *ShortenerCheck) Check(u *url.URL) *DestinationRejection {
+	if matchesDomain(normalizeHost(u.Hostname()), c.domains) {
+		return &DestinationRejection{
+			Reason:  ReasonURLShortener,
+			Message: "Links to other URL shorteners are not allowed",
+		}
+	}
+	return nil
+}
+
+// SelfReferenceCheck refuses links back to this service, which would
+// redirect to itself or chain short links.
+type SelfReferenceCheck struct {
+	hosts []string
+}
+
+// NewSelfReferenceCheck collects the hosts of the given base URLs; empty or
+// invalid ones are skipped.
+func NewSelfReferenceCheck(baseURLs ...string) *SelfReferenceCheck {
+	check := &SelfReferenceCheck{}
+	for _, raw := range baseURLs {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		check.hosts = append(check.hosts, normalizeHost(u.Hostname()))
+	}
+	return check
+}
+
+func (c *SelfReferenceCheck) Check(u *url.URL) *DestinationRejection {
+	host := normalizeHost(u.Hostname())
+	for _, self := range c.hosts {
+		if host == self {
+			return &DestinationRejection{
+				Reason:  ReasonSelfReference,
+				Message: "Links to this URL shortener itself are not allowed",
+			}
+		}
+	}
+	return nil
+}
+
+// BannedDomainCheck refuses domains banned by moderators. Unlike
+// DomainListCheck it changes at runtime.
+type BannedDomainCheck struct {
+	mu      sync.RWMutex
+	domains map[string]struct{}
+}
+
+func NewBannedDomainCheck(domains []string) *BannedDomainCheck {
+	check := &BannedDomainCheck{domains: make(map[string]struct{}, len(domains))}
+	for _, domain := range domains {
+		check.Add(domain)
+	}
+	return check
+}
+
+func (c *BannedDomainCheck) Add(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.domains[normalizeHost(domain)] = struct{}{}
+}
+
+// Replace swaps in the full list, as reloaded from the database.
+func (c *BannedDomainCheck) Replace(domains []string) {
+	replaced := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		replaced[normalizeHost(domain)] = struct{}{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.domains = replaced
+}
+
+func (c *BannedDomainCheck) Remove(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.domains, normalizeHost(domain))
+}
+
+func (c *BannedDomainCheck) Check(u *url.URL) *DestinationRejection {
+	host := normalizeHost(u.Hostname())
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Walk up the labels so a ban on a domain covers its subdomains.
+	for domain := host; domain != ""; {
+		if _, banned := c.domains[domain]; banned {
+			return &DestinationRejection{
+				Reason:  ReasonBannedDomain,
+				Message: fmt.Sprintf("Links to %s have been banned for abuse", host),
+			}
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return nil
+}
+
+// NormalizeDomain lowercases a domain entered by hand, accepting a full URL
+// as well. It returns an empty string if no host can be found.
+func NormalizeDomain(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, "://") {
+		if u, err := url.Parse(raw); err == nil {
+			raw = u.Hostname()
+		}
+	}
+	return normalizeHost(raw)
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func splitDomains(list string) []string {
+	var domains []string
+	for _, domain := range strings.Split(list, ",") {
+		if domain = normalizeHost(strings.TrimSpace(domain)); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const feistelRounds = 4
+
+// FeistelPermutation is a keyed bijection over [0, 2^bits). Feeding it
+// sequential IDs yields values that look random but never collide, and
+// Invert maps them back.
+type FeistelPermutation struct {
+	key      []byte
+	halfBits uint
+	halfMask uint64
+}
+
+func NewFeistelPermutation(key []byte, bits uint) (*FeistelPermutation, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("feistel key must not be empty")
+	}
+	if bits < 2 || bits > 62 || bits%2 != 0 {
+		return nil, fmt.Errorf("feistel domain must be an even number of bits between 2 and 62, got %d", bits)
+	}
+
+	halfBits := bits / 2
+	return &FeistelPermutation{
+		key:      key,
+		halfBits: halfBits,
+		halfMask: (uint64(1) << halfBits) - 1,
+	}, nil
+}
+
+// Max returns the size of the domain; inputs must be below it.
+func (p *FeistelPermutation) Max() uint64 {
+	return uint64(1) << (2 * p.halfBits)
+}
+
+func (p *FeistelPermutation) Permute(x uint64) uint64 {
+	left, right := (x>>p.halfBits)&p.halfMask, x&p.halfMask
+	for round := 0; round < feistelRounds; round++ {
+		left, right = right, left^p.round(round, right)
+	}
+	return left<<p.halfBits | right
+}
+
+func (p *FeistelPermutation) Invert(x uint64) uint64 {
+	left, right := (x>>p.halfBits)&p.halfMask, x&p.halfMask
+	for round := feistelRounds - 1; round >= 0; round-- {
+		left, right = right^p.round(round, left), left
+	}
+	return left<<p.halfBits | right
+}
+
+func (p *FeistelPermutation) round(round int, half uint64) uint64 {
+	var buf [9]byte
+	buf[0] = byte(round)
+	binary.BigEndian.PutUint64(buf[1:], half)
+
+	h := sha256.New()
+	h.Write(p.key)
+	h.Write(buf[:])
+	sum := h.Sum(nil)
+
+	return binary.BigEndian.Uint64(sum[:8]) & p.halfMask
+}
@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestFeistelPermutationIsBijective(t *testing.T) {
+	for _, bits := range []uint{2, 4, 6, 8, 10, 12} {
+		p, err := NewFeistelPermutation([]byte("test-key"), bits)
+		if err != nil {
+			t.Fatalf("bits %d: %v", bits, err)
+		}
+
+		seen := make(map[uint64]bool, p.Max())
+		for x := uint64(0); x < p.Max(); x++ {
+			y := p.Permute(x)
+			if y >= p.Max() {
+				t.Fatalf("bits %d: Permute(%d) = %d, outside the domain", bits, x, y)
+			}
+			if seen[y] {
+				t.Fatalf("bits %d: Permute(%d) = %d, already produced", bits, x, y)
+			}
+			seen[y] = true
+
+			if back := p.Invert(y); back != x {
+				t.Fatalf("bits %d: Invert(Permute(%d)) = %d", bits, x, back)
+			}
+		}
+	}
+}
+
+func TestNewFeistelPermutationRejectsBadDomains(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		bits uint
+	}{
+		{"empty key", "", 8},
+		{"too small", "key", 0},
+		{"odd", "key", 7},
+		{"too large", "key", 64},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewFeistelPermutation([]byte(tt.key), tt.bits); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	DefaultQRCodeSize = 256
+	MinQRCodeSize     = 64
+	MaxQRCodeSize     = 2048
+	// DefaultQRMargin is the quiet zone the QR spec asks for, in modules.
+	DefaultQRMargin = 4
+	MaxQRMargin     = 16
+
+	// qrLogoScale is the share of the symbol width a centered logo covers.
+	// At 20% it hides about 4% of the modules, well within what level Q
+	// recovers.
+	qrLogoScale = 0.2
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QROptions controls how a QR code is drawn. Size is the image width in
+// pixels and Margin the quiet zone in modules.
+type QROptions struct {
+	Size       int
+	Margin     int
+	Level      string
+	Foreground color.RGBA
+	Background color.RGBA
+	Logo       *QRLogo
+}
+
+func DefaultQROptions() QROptions {
+	return QROptions{
+		Size:       DefaultQRCodeSize,
+		Margin:     DefaultQRMargin,
+		Level:      "M",
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// QRLogo is an image drawn over the center of QR codes.
+type QRLogo struct {
+	image   image.Image
+	dataURI string
+}
+
+// LoadQRLogo reads a PNG or JPEG logo.
+func LoadQRLogo(path string) (*QRLogo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read QR logo: %w", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode QR logo: %w", err)
+	}
+
+	return &QRLogo{
+		image:   img,
+		dataURI: "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// ParseHexColor accepts RRGGBB or RRGGBBAA, with or without a leading #.
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+
+	c := color.RGBA{R: b[0], G: b[1], B: b[2], A: 0xff}
+	if len(b) == 4 {
+		c.A = b[3]
+	}
+	return c, nil
+}
+
+// qrModules encodes content and returns the module grid including the
+// margin. A logo covers part of the symbol, so it raises the error
+// correction to at least Q.
+func qrModules(content string, opts QROptions) ([][]bool, error) {
+	level, ok := qrLevels[strings.ToUpper(opts.Level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid error correction level %q", opts.Level)
+	}
+	if opts.Logo != nil && level < qrcode.High {
+		level = qrcode.High
+	}
+
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+	symbol := q.Bitmap()
+
+	n := len(symbol) + 2*opts.Margin
+	modules := make([][]bool, n)
+	for y := range modules {
+		modules[y] = make([]bool, n)
+	}
+	for y, row := range symbol {
+		copy(modules[y+opts.Margin][opts.Margin:], row)
+	}
+	return modules, nil
+}
+
+// qrLogoBox returns the logo square in modules: offset from the top-left
+// corner and width, rounded so it covers whole modules.
+func qrLogoBox(n, margin int) (int, int) {
+	width := int(float64(n-2*margin) * qrLogoScale)
+	if (n-width)%2 != 0 {
+		width++
+	}
+	return (n - width) / 2, width
+}
+
+// QRCodePNG draws a QR code as a PNG of opts.Size pixels square. Modules are
+// whole pixels so the code stays sharp; leftover pixels widen the margin.
+func QRCodePNG(content string, opts QROptions) ([]byte, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(modules)
+	size := max(opts.Size, n)
+	scale := size / n
+	offset := (size - scale*n) / 2
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			rect := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+			draw.Draw(img, rect, fg, image.Point{}, draw.Src)
+		}
+	}
+
+	if opts.Logo != nil {
+		start, width := qrLogoBox(n, opts.Margin)
+		box := image.Rect(offset+start*scale, offset+start*scale, offset+(start+width)*scale, offset+(start+width)*scale)
+		draw.Draw(img, box, image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		drawScaled(img, box.Inset(scale/2), opts.Logo.image)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// QRCodeSVG draws a QR code as SVG with one module per user unit, scaled to
+// opts.Size pixels.
+func QRCodeSVG(content string, opts QROptions) ([]byte, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	n := len(modules)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, opts.Size, opts.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d"%s/>`, n, n, svgFill(opts.Background))
+
+	// One path for all dark modules, each horizontal run as a single rect.
+	buf.WriteString(`<path d="`)
+	for y, row := range modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	fmt.Fprintf(&buf, `"%s/>`, svgFill(opts.Foreground))
+
+	if opts.Logo != nil {
+		start, width := qrLogoBox(n, opts.Margin)
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d"%s/>`, start, start, width, width, svgFill(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%g" y="%g" width="%g" height="%g" href="%s"/>`,
+			float64(start)+0.5, float64(start)+0.5, float64(width)-1, float64(width)-1, opts.Logo.dataURI)
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+func svgFill(c color.RGBA) string {
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%.3g"`, float64(c.A)/0xff)
+	}
+	return fill
+}
+
+// drawScaled fits src into dst's rect, keeping its aspect ratio, using
+// nearest-neighbour sampling.
+func drawScaled(dst draw.Image, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() || rect.Empty() {
+		return
+	}
+
+	w, h := rect.Dx(), rect.Dy()
+	if sb.Dx()*h > sb.Dy()*w {
+		h = sb.Dy() * w / sb.Dx()
+	} else {
+		w = sb.Dx() * h / sb.Dy()
+	}
+	x0 := rect.Min.X + (rect.Dx()-w)/2
+	y0 := rect.Min.Y + (rect.Dy()-h)/2
+
+	scaled := image.NewRGBA(image.Rect(x0, y0, x0+w, y0+h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			scaled.Set(x0+x, y0+y, src.At(sb.Min.X+x*sb.Dx()/w, sb.Min.Y+y*sb.Dy()/h))
+		}
+	}
+	draw.Draw(dst, scaled.Bounds(), scaled, scaled.Bounds().Min, draw.Over)
+}
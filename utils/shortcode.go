@@ -4,64 +4,140 @@ package utils
 import (
 	"crypto/rand"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
 )
 
 const (
 	base62Chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	base36Chars = "0123456789abcdefghijklmnopqrstuvwxyz"
 
-	DefaultLength = 7
+	// aliasChars are the extra characters allowed in custom aliases on top
+	// of base62Chars, so codes like "spring-sale" stay readable.
+	aliasChars = "-_"
+
+	DefaultLength      = 7
+	MaxShortCodeLength = 32
 )
 
-func GenerateShortCode(length int) (string, error) {
+var (
+	// Base62 is the default alphabet used for generated short codes.
+	Base62 = mustAlphabet(base62Chars)
+	// Base36 is the default alphabet when short codes are case-insensitive.
+	Base36 = mustAlphabet(base36Chars)
+)
+
+// Alphabet is the set of characters generated codes are drawn from. It must
+// be a subset of base62Chars so every generated code passes
+// ValidateShortCode.
+type Alphabet struct {
+	chars string
+	index map[byte]uint64
+}
+
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("alphabet needs at least 2 characters")
+	}
+
+	index := make(map[byte]uint64, len(chars))
+	for i := 0; i < len(chars); i++ {
+		char := chars[i]
+		if !strings.ContainsRune(base62Chars, rune(char)) {
+			return nil, fmt.Errorf("alphabet character %q is not alphanumeric", char)
+		}
+		if _, duplicate := index[char]; duplicate {
+			return nil, fmt.Errorf("alphabet character %q is repeated", char)
+		}
+		index[char] = uint64(i)
+	}
+
+	return &Alphabet{chars: chars, index: index}, nil
+}
+
+func mustAlphabet(chars string) *Alphabet {
+	alphabet, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return alphabet
+}
+
+func (a *Alphabet) String() string {
+	return a.chars
+}
+
+// HasCaseVariants reports whether the alphabet contains both cases of a
+// letter, which would make codes ambiguous once case is ignored.
+func (a *Alphabet) HasCaseVariants() bool {
+	for i := 0; i < len(a.chars); i++ {
+		char := a.chars[i]
+		if char >= 'a' && char <= 'z' && strings.IndexByte(a.chars, char-'a'+'A') >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Bits returns how many bits of entropy fit in a code of the given length.
+func (a *Alphabet) Bits(length int) float64 {
+	return float64(length) * math.Log2(float64(len(a.chars)))
+}
+
+func (a *Alphabet) Generate(length int) (string, error) {
 	if length <= 0 {
 		length = DefaultLength
 	}
 
 	result := make([]byte, length)
-	charsLen := big.NewInt(int64(len(base62Chars)))
+	charsLen := big.NewInt(int64(len(a.chars)))
 
 	for i := 0; i < length; i++ {
 		num, err := rand.Int(rand.Reader, charsLen)
 		if err != nil {
 			return "", err
 		}
-		result[i] = base62Chars[num.Int64()]
+		result[i] = a.chars[num.Int64()]
 	}
 
 	return string(result), nil
 }
 
-func EncodeBase62(num uint64) string {
+func (a *Alphabet) Encode(num uint64) string {
 	if num == 0 {
-		return string(base62Chars[0])
+		return string(a.chars[0])
 	}
 
 	result := make([]byte, 0)
-	base := uint64(len(base62Chars))
+	base := uint64(len(a.chars))
 
 	for num > 0 {
 		remainder := num % base
-		result = append([]byte{base62Chars[remainder]}, result...)
+		result = append([]byte{a.chars[remainder]}, result...)
 		num = num / base
 	}
 
 	return string(result)
 }
 
-func DecodeBase62(str string) (uint64, error) {
-	var num uint64
-	base := uint64(len(base62Chars))
-
-	charIndex := make(map[byte]uint64)
-	for i := 0; i < len(base62Chars); i++ {
-		charIndex[base62Chars[i]] = uint64(i)
+// EncodePadded encodes num and left-pads it with the zero digit so every code
+// in a keyspace has the same length.
+func (a *Alphabet) EncodePadded(num uint64, length int) string {
+	encoded := a.Encode(num)
+	if len(encoded) >= length {
+		return encoded
 	}
+	return strings.Repeat(string(a.chars[0]), length-len(encoded)) + encoded
+}
+
+func (a *Alphabet) Decode(str string) (uint64, error) {
+	var num uint64
+	base := uint64(len(a.chars))
 
 	for i := 0; i < len(str); i++ {
 		char := str[i]
-		value, exists := charIndex[char]
+		value, exists := a.index[char]
 		if !exists {
 			return 0, fmt.Errorf("invalid character: %c", char)
 		}
@@ -71,13 +147,29 @@ func DecodeBase62(str string) (uint64, error) {
 	return num, nil
 }
 
+func GenerateShortCode(length int) (string, error) {
+	return Base62.Generate(length)
+}
+
+func EncodeBase62(num uint64) string {
+	return Base62.Encode(num)
+}
+
+func EncodeBase62Padded(num uint64, length int) string {
+	return Base62.EncodePadded(num, length)
+}
+
+func DecodeBase62(str string) (uint64, error) {
+	return Base62.Decode(str)
+}
+
 func ValidateShortCode(code string) bool {
-	if len(code) < 1 || len(code) > 10 {
+	if len(code) < 1 || len(code) > MaxShortCodeLength {
 		return false
 	}
 
 	for _, char := range code {
-		if !strings.ContainsRune(base62Chars, char) {
+		if !strings.ContainsRune(base62Chars, char) && !strings.ContainsRune(aliasChars, char) {
 			return false
 		}
 	}
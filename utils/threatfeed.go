@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minThreatPrefixLength = 4
+	maxHostSuffixes       = 5
+	maxPathPrefixes       = 4
+)
+
+// ThreatFeed matches URLs against a local list of SHA-256 hash prefixes in
+// the style of Safe Browsing. The file holds one hex-encoded prefix (4 to 32
+// bytes) per line; blank lines and lines starting with # are ignored. Each
+// prefix is taken from the hash of a host/path expression such as
+// "evil.example/" or "evil.example/login.php", and a URL matches when the
+// hash of any of its expressions starts with a listed prefix.
+type ThreatFeed struct {
+	path string
+
+	mu       sync.RWMutex
+	modTime  time.Time
+	prefixes map[int]map[string]struct{}
+	count    int
+}
+
+// NewThreatFeed loads the feed at path. An empty path or a missing file gives
+// an empty feed that never matches until the file shows up.
+func NewThreatFeed(path string) (*ThreatFeed, error) {
+	feed := &ThreatFeed{path: path}
+	if path == "" {
+		return feed, nil
+	}
+
+	if _, err := feed.Reload(); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// Reload rereads the file if it changed since the last load and reports
+// whether it did.
+func (f *ThreatFeed) Reload() (bool, error) {
+	if f.path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot read threat feed: %w", err)
+	}
+
+	f.mu.RLock()
+	unchanged := info.ModTime().Equal(f.modTime)
+	f.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	prefixes, count, err := readThreatPrefixes(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	f.modTime = info.ModTime()
+	f.prefixes = prefixes
+	f.count = count
+	f.mu.Unlock()
+
+	return true, nil
+}
+
+// Len returns how many prefixes are loaded.
+func (f *ThreatFeed) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.count
+}
+
+func (f *ThreatFeed) Match(u *url.URL) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.count == 0 {
+		return false
+	}
+
+	for _, expression := range threatExpressions(u) {
+		sum := sha256.Sum256([]byte(expression))
+		for length, set := range f.prefixes {
+			if _, ok := set[string(sum[:length])]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check makes the feed usable as a DestinationCheck.
+func (f *ThreatFeed) Check(u *url.URL) *DestinationRejection {
+	if f.Match(u) {
+		return &DestinationRejection{
+			Reason:  ReasonThreatMatch,
+			Message: "The destination is listed as malicious",
+		}
+	}
+	return nil
+}
+
+func readThreatPrefixes(path string) (map[int]map[string]struct{}, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot read threat feed: %w", err)
+	}
+	defer file.Close()
+
+	prefixes := make(map[int]map[string]struct{})
+	count := 0
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		prefix, err := hex.DecodeString(text)
+		if err != nil || len(prefix) < minThreatPrefixLength || len(prefix) > sha256.Size {
+			return nil, 0, fmt.Errorf("threat feed line %d: expected a hex hash prefix of %d to %d bytes", line, minThreatPrefixLength, sha256.Size)
+		}
+
+		set, ok := prefixes[len(prefix)]
+		if !ok {
+			set = make(map[string]struct{})
+			prefixes[len(prefix)] = set
+		}
+		if _, duplicate := set[string(prefix)]; !duplicate {
+			set[string(prefix)] = struct{}{}
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("cannot read threat feed: %w", err)
+	}
+
+	return prefixes, count, nil
+}
+
+// threatExpressions lists the host suffix / path prefix combinations a URL
+// is looked up under: the exact host and up to four parent domains, each with
+// the full path and query, the path alone, and up to four leading path
+// segments.
+func threatExpressions(u *url.URL) []string {
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return nil
+	}
+
+	hosts := []string{host}
+	labels := strings.Split(host, ".")
+	for i := max(1, len(labels)-maxHostSuffixes); i < len(labels)-1; i++ {
+		hosts = append(hosts, strings.Join(labels[i:], "."))
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	paths := []string{path}
+	if u.RawQuery != "" {
+		paths = append(paths, path+"?"+u.RawQuery)
+	}
+	prefix := "/"
+	paths = append(paths, prefix)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1 && i < maxPathPrefixes-1; i++ {
+		prefix += segments[i] + "/"
+		paths = append(paths, prefix)
+	}
+
+	seen := make(map[string]bool)
+	var expressions []string
+	for _, h := range hosts {
+		for _, p := range paths {
+			expression := h + p
+			if !seen[expression] {
+				seen[expression] = true
+				expressions = append(expressions, expression)
+			}
+		}
+	}
+	return expressions
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestThreatExpressions(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{
+			"http://a.b.c/1/2.html?param=1",
+			[]string{
+				"a.b.c/1/2.html", "a.b.c/1/2.html?param=1", "a.b.c/", "a.b.c/1/",
+				"b.c/1/2.html", "b.c/1/2.html?param=1", "b.c/", "b.c/1/",
+			},
+		},
+		{
+			"http://Example.COM.",
+			[]string{"example.com/"},
+		},
+		{
+			"https://example.com/",
+			[]string{"example.com/"},
+		},
+		{
+			"http://a.b.c.d.e.f.g/1.html",
+			[]string{
+				"a.b.c.d.e.f.g/1.html", "a.b.c.d.e.f.g/",
+				"c.d.e.f.g/1.html", "c.d.e.f.g/",
+				"d.e.f.g/1.html", "d.e.f.g/",
+				"e.f.g/1.html", "e.f.g/",
+				"f.g/1.html", "f.g/",
+			},
+		},
+		{
+			"http://ex.com/1/2/3/4/5/6",
+			[]string{"ex.com/1/2/3/4/5/6", "ex.com/", "ex.com/1/", "ex.com/1/2/", "ex.com/1/2/3/"},
+		},
+		{
+			"mailto:someone@example.com",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := threatExpressions(u); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("threatExpressions(%q) =\n%q\nwant\n%q", tt.raw, got, tt.want)
+		}
+	}
+}
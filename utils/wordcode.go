@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	//go:embed wordlists/adjectives.txt
+	adjectivesList string
+	//go:embed wordlists/nouns.txt
+	nounsList string
+
+	adjectives = strings.Fields(adjectivesList)
+	nouns      = strings.Fields(nounsList)
+)
+
+const (
+	DefaultWordCodeWords  = 2
+	DefaultWordCodeDigits = 2
+
+	maxWordCodeWords = 3
+)
+
+// WordCodeMaxLength returns the longest code GenerateWordCode can produce
+// with the given settings.
+func WordCodeMaxLength(words, digits int) int {
+	length := (words-1)*longest(adjectives) + longest(nouns) + words - 1
+	if digits > 0 {
+		length += digits + 1
+	}
+	return length
+}
+
+func longest(list []string) int {
+	n := 0
+	for _, word := range list {
+		n = max(n, len(word))
+	}
+	return n
+}
+
+// GenerateWordCode builds a lowercase, dictation-friendly code such as
+// "brave-otter-42": words-1 adjectives, one noun and an optional numeric
+// suffix of the given number of digits. More words or digits mean more
+// randomness.
+func GenerateWordCode(words, digits int) (string, error) {
+	if words <= 0 {
+		words = DefaultWordCodeWords
+	}
+	if words > maxWordCodeWords {
+		return "", fmt.Errorf("word codes support at most %d words", maxWordCodeWords)
+	}
+	if digits < 0 {
+		digits = 0
+	}
+	if WordCodeMaxLength(words, digits) > MaxShortCodeLength {
+		return "", fmt.Errorf("word codes with %d words and %d digits may exceed %d characters", words, digits, MaxShortCodeLength)
+	}
+
+	parts := make([]string, 0, words+1)
+	for i := 0; i < words-1; i++ {
+		adjective, err := randomElement(adjectives)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, adjective)
+	}
+
+	noun, err := randomElement(nouns)
+	if err != nil {
+		return "", err
+	}
+	parts = append(parts, noun)
+
+	if digits > 0 {
+		limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+		num, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%0*d", digits, num))
+	}
+
+	return strings.Join(parts, "-"), nil
+}
+
+func randomElement(list []string) (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+	if err != nil {
+		return "", err
+	}
+	return list[i.Int64()], nil
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+)
+
+const defaultBannedDomainsInterval = time.Minute
+
+// BannedDomainSync keeps the in-memory banned domain list in step with the
+// banned_domains table, so a ban made on one instance reaches the others.
+type BannedDomainSync struct {
+	store    db.Store
+	check    *utils.BannedDomainCheck
+	interval time.Duration
+}
+
+func NewBannedDomainSync(config *utils.Config, store db.Store, check *utils.BannedDomainCheck) *BannedDomainSync {
+	domainSync := &BannedDomainSync{
+		store:    store,
+		check:    check,
+		interval: config.BannedDomainsInterval,
+	}
+
+	if domainSync.interval <= 0 {
+		domainSync.interval = defaultBannedDomainsInterval
+	}
+
+	return domainSync
+}
+
+// Reload replaces the list with the domains currently in the database.
+func (s *BannedDomainSync) Reload(ctx context.Context) error {
+	banned, err := s.store.ListBannedDomains(ctx)
+	if err != nil {
+		return err
+	}
+
+	domains := make([]string, len(banned))
+	for i, domain := range banned {
+		domains[i] = domain.Domain
+	}
+	s.check.Replace(domains)
+	return nil
+}
+
+func (s *BannedDomainSync) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Reload(ctx); err != nil {
+			log.Println("banned domains: failed to reload:", err)
+		}
+	}
+}
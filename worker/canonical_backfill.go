@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+)
+
+const canonicalBackfillBatchSize = 500
+
+// BackfillCanonicalURLs runs the Go canonicalizer over links created before
+// canonical_url existed. Migration 000006 could only copy original_url into
+// the column, so deduplication missed those links whenever canonicalizing
+// changes the URL. Only links whose canonical_url still equals original_url
+// are looked at, which makes the backfill cheap to repeat on every start.
+func BackfillCanonicalURLs(ctx context.Context, store db.Store, opts utils.CanonicalizeOptions) {
+	var lastID int64
+	var checked, updated int
+
+	for {
+		rows, err := store.ListUncanonicalizedURLsAfterID(ctx, db.ListUncanonicalizedURLsAfterIDParams{
+			ID:    lastID,
+			Limit: canonicalBackfillBatchSize,
+		})
+		if err != nil {
+			log.Println("canonical backfill: failed to list urls:", err)
+			return
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			checked++
+
+			canonicalUrl, err := utils.CanonicalizeURL(row.OriginalUrl, opts)
+			if err != nil || canonicalUrl == row.OriginalUrl {
+				continue
+			}
+
+			err = store.SetURLCanonical(ctx, db.SetURLCanonicalParams{
+				ID:           row.ID,
+				CanonicalUrl: canonicalUrl,
+			})
+			if err != nil {
+				log.Println("canonical backfill: failed to update url", row.ID, err)
+				continue
+			}
+			updated++
+		}
+
+		if len(rows) < canonicalBackfillBatchSize {
+			break
+		}
+	}
+
+	if updated > 0 {
+		log.Printf("canonical backfill: checked %d urls, updated %d", checked, updated)
+	}
+}
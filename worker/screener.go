@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+)
+
+const (
+	defaultThreatFeedInterval = time.Minute
+	screenBatchSize           = 500
+)
+
+// Screener watches the threat feed file and, whenever it changes, re-checks
+// every active link against it and takes down the ones that now match. A
+// link matches when any of its destinations does: long_url, the device URLs
+// and the destinations of its geo rules, variants and schedule rules.
+// Matches get abuse_status 'threat_feed', so they answer 410, show up in the
+// moderation queue and are kept by the retention sweep.
+type Screener struct {
+	store    db.Store
+	feed     *utils.ThreatFeed
+	interval time.Duration
+}
+
+func NewScreener(config *utils.Config, store db.Store, feed *utils.ThreatFeed) *Screener {
+	screener := &Screener{
+		store:    store,
+		feed:     feed,
+		interval: config.ThreatFeedInterval,
+	}
+
+	if screener.interval <= 0 {
+		screener.interval = defaultThreatFeedInterval
+	}
+
+	return screener
+}
+
+func (s *Screener) Start(ctx context.Context) {
+	// Links created while the service was down were never checked against
+	// the feed that is loaded now.
+	if s.feed.Len() > 0 {
+		s.screen(ctx)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := s.feed.Reload()
+		if err != nil {
+			log.Println("screener: failed to reload threat feed:", err)
+			continue
+		}
+		if changed {
+			log.Printf("screener: threat feed reloaded with %d prefixes", s.feed.Len())
+			s.screen(ctx)
+		}
+	}
+}
+
+func (s *Screener) screen(ctx context.Context) {
+	var lastID int64
+	var screened, deactivated int
+
+	for {
+		rows, err := s.store.ListActiveURLsAfterID(ctx, db.ListActiveURLsAfterIDParams{
+			ID:    lastID,
+			Limit: screenBatchSize,
+		})
+		if err != nil {
+			log.Println("screener: failed to list urls:", err)
+			return
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			screened++
+
+			if !s.matchesAny(row.Destinations) {
+				continue
+			}
+
+			quarantined, err := s.store.QuarantineURL(ctx, row.ID)
+			if err != nil {
+				log.Println("screener: failed to deactivate", row.ShortCode+":", err)
+				continue
+			}
+			if quarantined == 0 {
+				continue
+			}
+			deactivated++
+			log.Printf("screener: deactivated %s, destination matches the threat feed", row.ShortCode)
+		}
+
+		if len(rows) < screenBatchSize {
+			break
+		}
+	}
+
+	log.Printf("screener: screened %d urls, deactivated %d", screened, deactivated)
+}
+
+func (s *Screener) matchesAny(destinations []string) bool {
+	for _, destination := range destinations {
+		u, err := url.Parse(destination)
+		if err == nil && s.feed.Match(u) {
+			return true
+		}
+	}
+	return false
+}
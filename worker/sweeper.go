@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/utils"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// sweeperLockID is the Postgres advisory lock key shared by every
+	// instance, so only one of them sweeps per tick.
+	sweeperLockID = 0x75726c73776570
+
+	defaultSweeperInterval  = time.Minute
+	defaultSweeperBatchSize = 500
+)
+
+type SweeperStats struct {
+	Runs        int64 `json:"runs"`
+	Skipped     int64 `json:"skipped"`
+	Failed      int64 `json:"failed"`
+	Deactivated int64 `json:"deactivated"`
+	Deleted     int64 `json:"deleted"`
+}
+
+// Sweeper periodically deactivates expired links and, when a retention
+// period is configured, hard-deletes links that stayed inactive past it.
+type Sweeper struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int32
+	retention time.Duration
+
+	runs        atomic.Int64
+	skipped     atomic.Int64
+	failed      atomic.Int64
+	deactivated atomic.Int64
+	deleted     atomic.Int64
+}
+
+func NewSweeper(config *utils.Config, store db.Store) *Sweeper {
+	sweeper := &Sweeper{
+		store:     store,
+		interval:  config.SweeperInterval,
+		batchSize: config.SweeperBatchSize,
+		retention: config.InactiveRetention,
+	}
+
+	if sweeper.interval <= 0 {
+		sweeper.interval = defaultSweeperInterval
+	}
+	if sweeper.batchSize <= 0 {
+		sweeper.batchSize = defaultSweeperBatchSize
+	}
+
+	return sweeper
+}
+
+func (s *Sweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sweeper) Stats() SweeperStats {
+	return SweeperStats{
+		Runs:        s.runs.Load(),
+		Skipped:     s.skipped.Load(),
+		Failed:      s.failed.Load(),
+		Deactivated: s.deactivated.Load(),
+		Deleted:     s.deleted.Load(),
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context) {
+	sweepCtx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	var retention pgtype.Interval
+	if s.retention > 0 {
+		retention = pgtype.Interval{Microseconds: s.retention.Microseconds(), Valid: true}
+	}
+
+	result, err := s.store.SweepURLsTx(sweepCtx, db.SweepURLsTxParams{
+		LockID:    sweeperLockID,
+		BatchSize: s.batchSize,
+		Retention: retention,
+	})
+	if err != nil {
+		s.failed.Add(1)
+		log.Println("sweeper: failed to sweep urls:", err)
+		return
+	}
+
+	if !result.Locked {
+		s.skipped.Add(1)
+		return
+	}
+
+	s.runs.Add(1)
+	s.deactivated.Add(int64(len(result.Deactivated)))
+	s.deleted.Add(result.Deleted)
+
+	if len(result.Deactivated) > 0 || result.Deleted > 0 {
+		log.Printf("sweeper: deactivated %d expired urls, deleted %d inactive urls", len(result.Deactivated), result.Deleted)
+	}
+}